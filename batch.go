@@ -0,0 +1,99 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// InfoBatch fetches the info for all tokens using at most concurrency
+// concurrent requests. The results and errors are keyed by token; a token is
+// present in exactly one of both maps. When the Clef API throttles the
+// lookups, all workers pause for the requested Retry-After delay. When the
+// API hasn't been initialized every token maps to ErrNotInitialized.
+func (api *API) InfoBatch(ctx context.Context, tokens []string, concurrency int) (map[string]*InfoResponse, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := map[string]*InfoResponse{}
+	errs := map[string]error{}
+
+	if err := api.ready(); err != nil {
+		for _, token := range tokens {
+			errs[token] = err
+		}
+
+		return results, errs
+	}
+
+	var (
+		mu         sync.Mutex
+		pauseUntil time.Time
+		wg         sync.WaitGroup
+	)
+
+	work := make(chan string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for token := range work {
+				mu.Lock()
+				pause := pauseUntil.Sub(api.clock.Now())
+				mu.Unlock()
+
+				var (
+					ir  *InfoResponse
+					err error
+				)
+
+				if pause <= 0 {
+					err = ctx.Err()
+				} else {
+					err = api.clock.Sleep(ctx, pause)
+				}
+
+				if err == nil {
+					ir, err = api.InfoContext(ctx, token)
+				}
+
+				var e *Error
+				if errors.As(err, &e) && IsRateLimitError(e) && e.RetryAfter > 0 {
+					mu.Lock()
+					if until := api.clock.Now().Add(e.RetryAfter); until.After(pauseUntil) {
+						pauseUntil = until
+					}
+					mu.Unlock()
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[token] = err
+				} else {
+					results[token] = ir
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := map[string]bool{}
+	for _, token := range tokens {
+		if seen[token] {
+			continue
+		}
+
+		seen[token] = true
+		work <- token
+	}
+
+	close(work)
+	wg.Wait()
+
+	return results, errs
+}
@@ -0,0 +1,45 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestInfoBatch(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") == "bad" {
+			jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`)(w, r)
+			return
+		}
+
+		jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1}}`)(w, r)
+	})
+
+	results, errs := api.InfoBatch(context.Background(), []string{"a", "b", "bad", "a"}, 2)
+
+	if len(results) != 2 || results["a"] == nil || results["b"] == nil {
+		t.Errorf("results = %v, want a and b", results)
+	}
+
+	if len(errs) != 1 || !IsInvalidTokenError(errs["bad"]) {
+		t.Errorf("errs = %v, want an invalid token error for bad", errs)
+	}
+}
+
+func TestInfoBatchNotInitialized(t *testing.T) {
+	for _, api := range []*API{nil, {}} {
+		results, errs := api.InfoBatch(context.Background(), []string{"a", "b"}, 2)
+
+		if len(results) != 0 {
+			t.Errorf("results = %v, want none", results)
+		}
+
+		for _, token := range []string{"a", "b"} {
+			if !errors.Is(errs[token], ErrNotInitialized) {
+				t.Errorf("errs[%q] = %v, want ErrNotInitialized", token, errs[token])
+			}
+		}
+	}
+}
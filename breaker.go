@@ -0,0 +1,105 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen will be returned without contacting the Clef API while the
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("clef: circuit breaker open")
+
+// WithCircuitBreaker fails requests fast with ErrCircuitOpen after
+// failureThreshold consecutive failures (network errors and 5xx responses).
+// After cooldown a single probe request is let through; when it succeeds the
+// circuit closes again, otherwise it stays open for another cooldown.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) Option {
+	return func(api *API) error {
+		if failureThreshold < 1 {
+			return fmt.Errorf("clef: invalid failure threshold %d", failureThreshold)
+		}
+
+		api.breaker = &breaker{
+			threshold: failureThreshold,
+			cooldown:  cooldown,
+		}
+		return nil
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow returns ErrCircuitOpen when a request may not be sent
+func (b *breaker) allow(now time.Time) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+
+		// let a single probe request through
+		b.state = breakerHalfOpen
+	case breakerHalfOpen:
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
+
+// record registers the outcome of an allowed request
+func (b *breaker) record(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// a cancelled probe says nothing about the api, let the next request
+	// probe again
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+		return
+	}
+
+	if !isTransient(err) {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
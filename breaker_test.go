@@ -0,0 +1,73 @@
+package clef
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		requests int32
+		healthy  atomic.Bool
+	)
+
+	clock := newFakeClock()
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if healthy.Load() {
+			jsonHandler(http.StatusOK, infoOK)(w, r)
+			return
+		}
+
+		jsonHandler(http.StatusServiceUnavailable, `{"error":"Service unavailable."}`)(w, r)
+	}, WithCircuitBreaker(2, time.Minute), WithClock(clock))
+
+	steps := []struct {
+		name     string
+		before   func()
+		open     bool
+		ok       bool
+		requests int32
+	}{
+		{"closed, first failure", func() {}, false, false, 1},
+		{"closed, threshold reached", func() {}, false, false, 2},
+		{"open", func() {}, true, false, 2},
+		{"open during cooldown", func() { clock.Advance(59 * time.Second) }, true, false, 2},
+		{"half open, probe fails", func() { clock.Advance(time.Second) }, false, false, 3},
+		{"open again", func() {}, true, false, 3},
+		{"half open, probe succeeds", func() { clock.Advance(time.Minute); healthy.Store(true) }, false, true, 4},
+		{"closed", func() {}, false, true, 5},
+	}
+
+	for _, step := range steps {
+		step.before()
+
+		_, err := api.Info("token")
+
+		if got := errors.Is(err, ErrCircuitOpen); got != step.open {
+			t.Errorf("%s: error = %v, want circuit open %t", step.name, err, step.open)
+		}
+
+		if got := err == nil; got != step.ok {
+			t.Errorf("%s: error = %v, want success %t", step.name, err, step.ok)
+		}
+
+		if got := atomic.LoadInt32(&requests); got != step.requests {
+			t.Errorf("%s: requests = %d, want %d", step.name, got, step.requests)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	api := newTestAPI(t, jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`), WithCircuitBreaker(1, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.Info("token"); !IsInvalidTokenError(err) {
+			t.Errorf("error = %v, want invalid token error", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package clef
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ButtonConfig contains the data for Clef's login button widget
+// (https://clef.io/v3/clef.js). Templates render the data attributes of the
+// button element explicitly:
+//
+//	<input type="submit" class="clef-button" data-app-id="{{ .AppID }}" data-redirect-url="{{ .RedirectURL }}" data-style="{{ .Style }}" data-state="{{ .State }}"/>
+//
+// or all of them at once using Attributes:
+//
+//	<input type="submit" class="clef-button" {{ .Attributes }}/>
+//
+// DataFields maps the widget attributes, eg. data-app-id, data-redirect-url,
+// data-style, data-state and data-scope, to their values.
+type ButtonConfig struct {
+	AppID       string
+	RedirectURL string
+	Style       string
+	State       string
+
+	DataFields map[string]string
+}
+
+// dataAttrRe matches the data attribute names that are safe to render
+var dataAttrRe = regexp.MustCompile(`^data-[a-z0-9-]+$`)
+
+// ButtonConfig returns the configuration of the login button redirecting to
+// redirectURL. The login options (WithStyle, WithState, WithScopes) are
+// applied to the button as data attributes. An empty configuration is
+// returned when the API hasn't been initialized.
+func (api *API) ButtonConfig(redirectURL string, opts ...LoginOption) ButtonConfig {
+	if api.ready() != nil {
+		return ButtonConfig{}
+	}
+
+	params := url.Values{}
+	params.Set("app_id", api.appID())
+	params.Set("redirect_url", redirectURL)
+
+	for _, opt := range opts {
+		opt(params)
+	}
+
+	fields := map[string]string{}
+	for k := range params {
+		fields["data-"+strings.Replace(k, "_", "-", -1)] = params.Get(k)
+	}
+
+	return ButtonConfig{
+		AppID:       params.Get("app_id"),
+		RedirectURL: params.Get("redirect_url"),
+		Style:       params.Get("style"),
+		State:       params.Get("state"),
+		DataFields:  fields,
+	}
+}
+
+// Attributes renders the data fields as escaped html attributes, sorted by
+// name, for use in html/template. Fields with names that aren't valid data
+// attributes are skipped.
+func (bc ButtonConfig) Attributes() template.HTMLAttr {
+	names := make([]string, 0, len(bc.DataFields))
+	for name := range bc.DataFields {
+		if dataAttrRe.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	attrs := make([]string, len(names))
+	for i, name := range names {
+		attrs[i] = name + `="` + html.EscapeString(bc.DataFields[name]) + `"`
+	}
+
+	return template.HTMLAttr(strings.Join(attrs, " "))
+}
@@ -0,0 +1,68 @@
+package clef
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestButtonConfigTemplates(t *testing.T) {
+	api, err := New("app-id", "app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bc := api.ButtonConfig(`https://app/cb?a=1&b="2"`, WithStyle("flat"), WithState("st<ate>"))
+	bc.DataFields["onclick=alert(1) data-x"] = "injected"
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			"explicit",
+			`<input data-app-id="{{ .AppID }}" data-redirect-url="{{ .RedirectURL }}" data-style="{{ .Style }}" data-state="{{ .State }}"/>`,
+			`<input data-app-id="app-id" data-redirect-url="https://app/cb?a=1&amp;b=%222%22" data-style="flat" data-state="st&lt;ate&gt;"/>`,
+		},
+		{
+			"attributes",
+			`<input {{ .Attributes }}/>`,
+			`<input data-app-id="app-id" data-redirect-url="https://app/cb?a=1&amp;b=&#34;2&#34;" data-state="st&lt;ate&gt;" data-style="flat"/>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := template.Must(template.New("").Parse(tt.tmpl)).Execute(&buf, bc); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("rendered\n%s\nwant\n%s", got, tt.want)
+			}
+
+			if strings.Contains(buf.String(), "ZgotmplZ") || strings.Contains(buf.String(), "onclick") {
+				t.Errorf("unsafe output %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestNotInitializedHelpersDontPanic(t *testing.T) {
+	for _, api := range []*API{nil, {}} {
+		if bc := api.ButtonConfig("https://app/cb"); bc.AppID != "" || len(bc.DataFields) != 0 {
+			t.Errorf("ButtonConfig() = %+v, want empty", bc)
+		}
+
+		if params := api.LogoutParams("token"); len(params) != 0 {
+			t.Errorf("LogoutParams() = %v, want empty", params)
+		}
+
+		if u := api.LoginURL("https://app/cb"); u != "" {
+			t.Errorf("LoginURL() = %q, want empty", u)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package clef
+
+import (
+	"sync"
+	"time"
+)
+
+// WithInfoCache caches Info responses per access token for ttl, so repeated
+// session validations don't hit the Clef API on every request. Use
+// InvalidateInfo to flush a token on logout.
+func WithInfoCache(ttl time.Duration) Option {
+	return func(api *API) error {
+		api.infoCache = &infoCache{
+			ttl:     ttl,
+			entries: map[string]infoCacheEntry{},
+		}
+		return nil
+	}
+}
+
+// InvalidateInfo removes the cached Info response for accessToken
+func (api *API) InvalidateInfo(accessToken string) {
+	if api == nil || api.infoCache == nil {
+		return
+	}
+
+	api.infoCache.delete(accessToken)
+}
+
+type infoCacheEntry struct {
+	ir      InfoResponse
+	expires time.Time
+}
+
+type infoCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]infoCacheEntry
+}
+
+func (c *infoCache) get(accessToken string, now time.Time) (*InfoResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[accessToken]
+	if !ok {
+		return nil, false
+	}
+
+	if now.After(e.expires) {
+		delete(c.entries, accessToken)
+		return nil, false
+	}
+
+	return e.ir.clone(), true
+}
+
+func (c *infoCache) set(accessToken string, ir *InfoResponse, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// drop expired entries, so tokens that are never requested again don't
+	// accumulate
+	for token, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, token)
+		}
+	}
+
+	c.entries[accessToken] = infoCacheEntry{
+		ir:      *ir.clone(),
+		expires: now.Add(c.ttl),
+	}
+}
+
+// clone returns a deep copy of ir, so callers can't modify a shared response
+func (ir *InfoResponse) clone() *InfoResponse {
+	c := *ir
+	if ir.Info != nil {
+		info := *ir.Info
+		c.Info = &info
+	}
+
+	if ir.Scopes != nil {
+		c.Scopes = append([]Scope(nil), ir.Scopes...)
+	}
+
+	return &c
+}
+
+func (c *infoCache) delete(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, accessToken)
+}
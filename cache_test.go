@@ -0,0 +1,73 @@
+package clef
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInfoCache(t *testing.T) {
+	var requests int32
+
+	clock := newFakeClock()
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1,"email":"jane@example.com"},"scopes":["email"]}`)(w, r)
+	}, WithInfoCache(time.Minute), WithClock(clock))
+
+	steps := []struct {
+		name     string
+		do       func()
+		requests int32
+	}{
+		{"first call", func() {}, 1},
+		{"cached", func() { clock.Advance(30 * time.Second) }, 1},
+		{"expired", func() { clock.Advance(31 * time.Second) }, 2},
+		{"invalidated", func() { api.InvalidateInfo("token") }, 3},
+	}
+
+	for _, step := range steps {
+		step.do()
+
+		ir, err := api.Info("token")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := atomic.LoadInt32(&requests); got != step.requests {
+			t.Errorf("%s: requests = %d, want %d", step.name, got, step.requests)
+		}
+
+		// callers can't modify the cached response
+		ir.Info.Email = "mutated"
+		ir.Scopes = append(ir.Scopes[:0], ScopePhone)
+	}
+
+	if ir, err := api.Info("token"); err != nil || ir.Info.Email != "jane@example.com" || !ir.HasScope(ScopeEmail) {
+		t.Errorf("Info() = %+v, %v, want the unmodified cached info", ir, err)
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		opts []Option
+	}{
+		{"uncached", nil},
+		{"cached", []Option{WithInfoCache(time.Hour)}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			api := newTestAPI(b, jsonHandler(http.StatusOK, infoOK), bm.opts...)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := api.Info("token"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
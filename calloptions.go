@@ -0,0 +1,119 @@
+package clef
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+)
+
+// CallOption configures a single API call
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout        time.Duration
+	idempotencyKey string
+	redirectURL    string
+	extraParams    map[string]string
+	logoutSource   LogoutSource
+}
+
+// reservedParams can't be overridden using WithExtraParams
+var reservedParams = map[string]bool{
+	"app_id":       true,
+	"app_secret":   true,
+	"code":         true,
+	"logout_token": true,
+	"access_token": true,
+}
+
+// WithTimeout sets a deadline of d for the call, including retries
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header of a Swag order, so
+// accidental double submits can be deduplicated by the server. By default a
+// random key is generated for every call.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithRedirectURL sends redirect_url along with an Authorize call. Apps that
+// use several callback routes should pass the same url that was used to build
+// the login url, so Clef can validate it.
+func WithRedirectURL(redirectURL string) CallOption {
+	return func(o *callOptions) {
+		o.redirectURL = redirectURL
+	}
+}
+
+// WithExtraParams adds params to the form of an Authorize, Logout or Swag
+// call, or the query of an Info call, so optional parameters that aren't
+// modeled by this package can be passed. Reserved fields, such as
+// app_secret, and fields already set by the call are never overwritten.
+func WithExtraParams(params map[string]string) CallOption {
+	return func(o *callOptions) {
+		if o.extraParams == nil {
+			o.extraParams = map[string]string{}
+		}
+
+		for k, v := range params {
+			o.extraParams[k] = v
+		}
+	}
+}
+
+// WithLogoutSource records what initiated a Logout call, it is returned in
+// the response and logged for auditing. Defaults to SourceInteractive.
+func WithLogoutSource(source LogoutSource) CallOption {
+	return func(o *callOptions) {
+		o.logoutSource = source
+	}
+}
+
+// newIdempotencyKey returns a random idempotency key
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func newCallOptions(opts []CallOption) callOptions {
+	o := callOptions{logoutSource: SourceInteractive}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// addExtraParams merges the extra params into form (or query)
+func (o callOptions) addExtraParams(form url.Values) {
+	for k, v := range o.extraParams {
+		if reservedParams[k] {
+			continue
+		} else if _, ok := form[k]; ok {
+			continue
+		}
+
+		form.Set(k, v)
+	}
+}
+
+// context derives the context for the call from ctx
+func (o callOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(ctx, o.timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
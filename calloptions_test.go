@@ -0,0 +1,104 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithExtraParams(t *testing.T) {
+	extra := WithExtraParams(map[string]string{
+		"foo":          "bar",
+		"app_secret":   "evil",
+		"access_token": "evil",
+		"code":         "evil",
+		"logout_token": "evil",
+		"name":         "evil",
+	})
+
+	tests := []struct {
+		name string
+		body string
+		call func(api *API) error
+		want map[string]string
+	}{
+		{"authorize", `{"success":true,"access_token":"t"}`, func(api *API) error {
+			_, err := api.Authorize("code", extra)
+			return err
+		}, map[string]string{"code": "code"}},
+		{"logout", `{"success":true,"clef_id":1}`, func(api *API) error {
+			_, err := api.Logout("logout-token", extra)
+			return err
+		}, map[string]string{"logout_token": "logout-token"}},
+		{"info", `{"success":true,"info":{"id":1}}`, func(api *API) error {
+			_, err := api.Info("access-token", extra)
+			return err
+		}, map[string]string{"access_token": "access-token"}},
+		{"info raw", `{"success":true,"info":{"id":1}}`, func(api *API) error {
+			_, err := api.InfoRawContext(context.Background(), "access-token", extra)
+			return err
+		}, map[string]string{"access_token": "access-token"}},
+		{"swag", `{"success":true}`, func(api *API) error {
+			_, err := api.Swag(&SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"}, extra)
+			return err
+		}, map[string]string{"name": "Jane"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, got := recordingAPI(t, tt.body, WithInfoCache(time.Minute), WithSingleFlight())
+
+			if err := tt.call(api); err != nil {
+				t.Fatal(err)
+			}
+
+			if v := got.Get("foo"); v != "bar" {
+				t.Errorf("foo = %q, want bar", v)
+			}
+
+			if v := got.Get("app_secret"); v != "" && v != "app-secret" {
+				t.Errorf("app_secret = %q, reserved field was overwritten", v)
+			}
+
+			for k, want := range tt.want {
+				if v := (*got)[k]; len(v) != 1 || v[0] != want {
+					t.Errorf("%s = %q, want [%q]", k, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+
+		jsonHandler(http.StatusOK, `{"success":true,"access_token":"t","clef_id":1,"info":{"id":1}}`)(w, r)
+	})
+
+	tests := []struct {
+		name string
+		call func(opt CallOption) error
+	}{
+		{"authorize", func(opt CallOption) error { _, err := api.Authorize("code", opt); return err }},
+		{"logout", func(opt CallOption) error { _, err := api.Logout("token", opt); return err }},
+		{"info", func(opt CallOption) error { _, err := api.Info("token", opt); return err }},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+
+		if err := tt.call(WithTimeout(50 * time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: error = %v, want context.DeadlineExceeded", tt.name, err)
+		}
+
+		if d := time.Since(start); d > 500*time.Millisecond {
+			t.Errorf("%s: returned after %s, want it to return at the timeout", tt.name, d)
+		}
+	}
+}
@@ -1,61 +1,119 @@
+// Package clef implements a client for the Clef API.
+//
+// Create an API instance with New for every Clef application; instances are
+// independent and safe to use from multiple goroutines, so one process can
+// serve multiple applications:
+//
+//	api, err := clef.New(appID, appSecret)
+//	ir, err := api.Info(accessToken)
+//
+// The package level functions (Initialize, Authorize, Info, Logout) are a
+// convenience layer over a single default instance.
 package clef
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"runtime"
+	"strconv"
 	"strings"
-
-	logging "github.com/op/go-logging"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	// Version is the implemented clef interface version
+	// Version is the implemented clef interface version, the default api
+	// version used in request paths
 	Version = "v1"
 )
 
-var log = logging.MustGetLogger("clef")
+const defaultBaseURL = "https://clef.io/api/"
 
-// internal API, used for direct clef.{Authorize,Info,Logout} calls
-var api *API
+// DefaultMaxResponseBytes is the default limit on the size of a response body
+const DefaultMaxResponseBytes = 1 << 20
 
-// API contains the ClefAPI object
-type API struct {
-	*http.Client
-
-	baseURL *url.URL
-	id      string
-	secret  string
-}
+// DefaultUserAgent is the User-Agent header sent with every request unless
+// overridden using WithUserAgent
+var DefaultUserAgent = "goclef/" + Version + " (" + runtime.Version() + ")"
 
-// Error contains Clef Error messages
-type Error struct {
-	Message       string `json:"message"`
-	Context       string `json:"context"`
-	InternalError string `json:"error"`
-}
+// DefaultAccept is the Accept header sent with every request unless
+// overridden using WithAccept
+const DefaultAccept = "application/json"
 
-// Error implements error interface
-func (e Error) Error() string {
-	return e.InternalError
-}
+// default API, used for direct clef.{Authorize,Info,Logout} calls
+var defaultAPI atomic.Pointer[API]
 
-// IsInvalidTokenError returns true if err is a invalid token error.
-func IsInvalidTokenError(err error) bool {
-	if e, ok := err.(*Error); ok {
-		return e.Message == "Invalid token."
-	}
+// API contains the ClefAPI object, use New to create one. The request
+// methods never panic on a nil or zero value API, they return
+// ErrNotInitialized instead.
+type API struct {
+	*http.Client
 
-	return false
+	customClient     bool
+	defaultTransport *http.Transport
+
+	baseURL       *url.URL
+	apiVersion    string
+	log           Logger
+	slowThreshold time.Duration
+	dumpRequests  bool
+	userAgent     string
+	accept        string
+
+	maxResponseBytes int64
+	strictDecoding   bool
+	codec            Codec
+
+	metrics       MetricsObserver
+	tracer        Tracer
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	inspector     func(*http.Request)
+
+	infoCache *infoCache
+	refresh   RefreshFunc
+	flights   *flightGroup
+	sem       chan struct{}
+	breaker   *breaker
+
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	maxRetryAfter  time.Duration
+
+	clock Clock
+
+	credMu sync.RWMutex
+	id     string
+	secret string
+
+	mu            sync.Mutex
+	rateLimit     RateLimit
+	serverVersion string
 }
 
 // ErrNotInitialized will be returned when the Clef API has not been
 // initialized yet.
 var ErrNotInitialized = errors.New("Clef API not initialized yet.")
 
+// ErrEmptyCode will be returned when an empty OAuth code is being exchanged
+var ErrEmptyCode = errors.New("clef: empty OAuth code")
+
+// ErrEmptyToken will be returned when an empty access or logout token is
+// being used.
+var ErrEmptyToken = errors.New("clef: empty token")
+
+// ErrResponseTooLarge will be returned when a response body exceeds the
+// configured maximum size.
+var ErrResponseTooLarge = errors.New("clef: response body too large")
+
 // MustInitialize initializes the Clef API and panic if error occurs
 func MustInitialize(appID, appSecret string) error {
 	if err := Initialize(appID, appSecret); err != nil {
@@ -67,152 +125,588 @@ func MustInitialize(appID, appSecret string) error {
 
 // Initialize Clef API with application id and application secret
 func Initialize(appID, appSecret string) error {
-	if c, err := newAPI(appID, appSecret); err != nil {
+	return InitializeWithOptions(appID, appSecret)
+}
+
+// InitializeWithClient initializes the Clef API with application id,
+// application secret and the http client used for all requests. When client
+// is nil http.DefaultClient will be used.
+func InitializeWithClient(appID, appSecret string, client *http.Client) error {
+	return InitializeWithOptions(appID, appSecret, WithHTTPClient(client))
+}
+
+// InitializeWithOptions initializes the Clef API with application id,
+// application secret and the supplied options
+func InitializeWithOptions(appID, appSecret string, opts ...Option) error {
+	if c, err := New(appID, appSecret, opts...); err != nil {
 		return err
 	} else {
-		api = c
+		defaultAPI.Store(c)
 		return nil
 	}
 }
 
+var (
+	initOnceMu   sync.Mutex
+	initOnceDone bool
+)
+
+// InitializeOnce initializes the Clef API like InitializeWithOptions, but
+// only once: after the first successful call, further calls are no-ops and
+// can't replace the instance mid-flight. A failed call can be retried.
+func InitializeOnce(appID, appSecret string, opts ...Option) error {
+	initOnceMu.Lock()
+	defer initOnceMu.Unlock()
+
+	if initOnceDone {
+		return nil
+	}
+
+	if err := InitializeWithOptions(appID, appSecret, opts...); err != nil {
+		return err
+	}
+
+	initOnceDone = true
+	return nil
+}
+
+// SetDefault sets the instance used by the package level functions
+func SetDefault(c *API) {
+	defaultAPI.Store(c)
+}
+
+// Initialized returns true if the default instance has been initialized
+func Initialized() bool {
+	return defaultAPI.Load() != nil
+}
+
+// Default returns the instance used by the package level functions, or
+// ErrNotInitialized when it hasn't been initialized yet.
+func Default() (*API, error) {
+	if api := defaultAPI.Load(); api != nil {
+		return api, nil
+	}
+
+	return nil, ErrNotInitialized
+}
+
 // Authorize exchanges an OAuth code for an OAuth token
-func Authorize(code string) (*AuthorizeResponse, error) {
+func Authorize(code string, opts ...CallOption) (*AuthorizeResponse, error) {
+	api := defaultAPI.Load()
 	if api == nil {
 		return nil, ErrNotInitialized
 	}
 
-	return api.Authorize(code)
+	return api.Authorize(code, opts...)
+}
+
+// AuthorizeContext exchanges an OAuth code for an OAuth token using ctx
+func AuthorizeContext(ctx context.Context, code string, opts ...CallOption) (*AuthorizeResponse, error) {
+	api := defaultAPI.Load()
+	if api == nil {
+		return nil, ErrNotInitialized
+	}
+
+	return api.AuthorizeContext(ctx, code, opts...)
 }
 
 // Logout will call Logout with the Clef API and return a LogoutResponse
-func Logout(logoutToken string) (*LogoutResponse, error) {
+func Logout(logoutToken string, opts ...CallOption) (*LogoutResponse, error) {
+	api := defaultAPI.Load()
+	if api == nil {
+		return nil, ErrNotInitialized
+	}
+
+	return api.Logout(logoutToken, opts...)
+}
+
+// LogoutContext will call Logout with the Clef API using ctx
+func LogoutContext(ctx context.Context, logoutToken string, opts ...CallOption) (*LogoutResponse, error) {
+	api := defaultAPI.Load()
 	if api == nil {
 		return nil, ErrNotInitialized
 	}
 
-	return api.Logout(logoutToken)
+	return api.LogoutContext(ctx, logoutToken, opts...)
 }
 
 // Info will return the info about the logged in Clef user
-func Info(accessToken string) (*InfoResponse, error) {
+func Info(accessToken string, opts ...CallOption) (*InfoResponse, error) {
+	api := defaultAPI.Load()
+	if api == nil {
+		return nil, ErrNotInitialized
+	}
+
+	return api.Info(accessToken, opts...)
+}
+
+// InfoContext will return the info about the logged in Clef user using ctx
+func InfoContext(ctx context.Context, accessToken string, opts ...CallOption) (*InfoResponse, error) {
+	api := defaultAPI.Load()
 	if api == nil {
 		return nil, ErrNotInitialized
 	}
 
-	return api.Info(accessToken)
+	return api.InfoContext(ctx, accessToken, opts...)
 }
 
-func newAPI(id, secret string) (*API, error) {
-	if baseURL, err := url.Parse("https://clef.io/api/"); err != nil {
+// New returns a new Clef API for application id and application secret.
+// The options are applied in order.
+func New(id, secret string, opts ...Option) (*API, error) {
+	api := &API{
+		id:               id,
+		secret:           secret,
+		Client:           http.DefaultClient,
+		log:              nopLogger{},
+		clock:            realClock{},
+		userAgent:        DefaultUserAgent,
+		accept:           DefaultAccept,
+		maxAttempts:      1,
+		maxRetryAfter:    DefaultMaxRetryAfter,
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+
+	opts = append([]Option{WithBaseURL(defaultBaseURL)}, opts...)
+
+	for _, opt := range opts {
+		if err := opt(api); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := api.applyTransport(); err != nil {
 		return nil, err
-	} else {
-		return &API{
-			id:      id,
-			secret:  secret,
-			baseURL: baseURL,
-			Client:  http.DefaultClient,
-		}, nil
 	}
+
+	return api, nil
+}
+
+// Close releases the idle connections of the transport of the API. The
+// shared http.DefaultTransport is left untouched. It is safe to call Close
+// on shutdown or when replacing the API, eg. after rotating credentials.
+func (api *API) Close() {
+	if api == nil || api.Client == nil {
+		return
+	}
+
+	if t, ok := api.Client.Transport.(*http.Transport); ok && t != http.DefaultTransport {
+		t.CloseIdleConnections()
+	}
+}
+
+// NewAPIWithClient returns a new Clef API using client for all requests.
+// When client is nil http.DefaultClient will be used.
+func NewAPIWithClient(id, secret string, client *http.Client) (*API, error) {
+	return New(id, secret, WithHTTPClient(client))
+}
+
+// successer is implemented by responses carrying a success field
+type successer interface {
+	succeeded() bool
 }
 
 // AuthorizeResponse contains the response of the Authorize call
 type AuthorizeResponse struct {
-	AccessToken string `json:"access_token"`
-	Success     bool   `json:"success"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Success      bool   `json:"success"`
+
+	// ExpiresIn is the lifetime of the access token in seconds, 0 when the
+	// token doesn't expire
+	ExpiresIn int `json:"expires_in,omitempty"`
+
+	// ExpiresAt is the time the access token expires, computed from
+	// ExpiresIn when the response is received
+	ExpiresAt time.Time `json:"-"`
+}
+
+func (ar *AuthorizeResponse) succeeded() bool { return ar.Success }
+
+// TimeUntilExpiry returns the remaining lifetime of the access token at now,
+// which is negative once the token has expired. Tokens that don't expire
+// return the maximum duration.
+func (ar *AuthorizeResponse) TimeUntilExpiry(now time.Time) time.Duration {
+	if ar.ExpiresAt.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return ar.ExpiresAt.Sub(now)
 }
 
 // Authorize exchanges an OAuth code for an OAuth token
-func (api *API) Authorize(code string) (*AuthorizeResponse, error) {
+func (api *API) Authorize(code string, opts ...CallOption) (*AuthorizeResponse, error) {
+	return api.AuthorizeContext(context.Background(), code, opts...)
+}
+
+// AuthorizeContext exchanges an OAuth code for an OAuth token. The request
+// is cancelled when ctx is done.
+func (api *API) AuthorizeContext(ctx context.Context, code string, opts ...CallOption) (*AuthorizeResponse, error) {
+	ar, _, err := api.AuthorizeWithMeta(ctx, code, opts...)
+	return ar, err
+}
+
+// AuthorizeWithMeta exchanges an OAuth code for an OAuth token and returns
+// the response metadata as well.
+func (api *API) AuthorizeWithMeta(ctx context.Context, code string, opts ...CallOption) (*AuthorizeResponse, ResponseMeta, error) {
+	if err := api.ready(); err != nil {
+		return nil, ResponseMeta{}, err
+	}
+
+	if code == "" {
+		return nil, ResponseMeta{}, ErrEmptyCode
+	}
+
+	o := newCallOptions(opts)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
 	form := url.Values{}
 	form.Add("code", code)
-	form.Add("app_id", api.id)
-	form.Add("app_secret", api.secret)
+	if o.redirectURL != "" {
+		form.Add("redirect_url", o.redirectURL)
+	}
+	api.addCredentials(form)
+	o.addExtraParams(form)
 
-	ar := AuthorizeResponse{}
-	if request, err := api.NewRequest("POST", "authorize", form); err != nil {
-		return nil, err
-	} else if err := api.Do(request, &ar); err != nil {
-		return nil, err
+	if request, err := api.NewRequestContext(ctx, "POST", "authorize", form); err != nil {
+		return nil, ResponseMeta{}, err
+	} else if ar, meta, err := do[AuthorizeResponse](api, request); err != nil {
+		return nil, meta, err
 	} else {
-		return &ar, nil
+		if ar.ExpiresIn > 0 {
+			ar.ExpiresAt = api.clock.Now().Add(time.Duration(ar.ExpiresIn) * time.Second)
+		}
+
+		return ar, meta, nil
 	}
 }
 
+// LogoutSource tells what initiated a logout
+type LogoutSource string
+
+const (
+	// SourceInteractive is a logout initiated by the user in the
+	// application, the default
+	SourceInteractive LogoutSource = "interactive"
+	// SourceWebhook is a logout initiated from the phone of the user,
+	// reported by the Clef logout webhook
+	SourceWebhook LogoutSource = "webhook"
+)
+
 // LogoutResponse contains the response of the Logout call
 type LogoutResponse struct {
-	ID      int  `json:"clef_id"`
-	Success bool `json:"success"`
+	ID      ClefID `json:"clef_id"`
+	Success bool   `json:"success"`
+
+	// Source is the source of the logout, as passed to WithLogoutSource
+	Source LogoutSource `json:"-"`
 }
 
+func (lr *LogoutResponse) succeeded() bool { return lr.Success }
+
 // Logout exchanges a logout token for a Clef ID
-func (api *API) Logout(logoutToken string) (*LogoutResponse, error) {
+func (api *API) Logout(logoutToken string, opts ...CallOption) (*LogoutResponse, error) {
+	return api.LogoutContext(context.Background(), logoutToken, opts...)
+}
+
+// LogoutContext exchanges a logout token for a Clef ID. The request is
+// cancelled when ctx is done.
+func (api *API) LogoutContext(ctx context.Context, logoutToken string, opts ...CallOption) (*LogoutResponse, error) {
+	lr, _, err := api.LogoutWithMeta(ctx, logoutToken, opts...)
+	return lr, err
+}
+
+// LogoutWithMeta exchanges a logout token for a Clef ID and returns the
+// response metadata as well.
+func (api *API) LogoutWithMeta(ctx context.Context, logoutToken string, opts ...CallOption) (*LogoutResponse, ResponseMeta, error) {
+	if err := api.ready(); err != nil {
+		return nil, ResponseMeta{}, err
+	}
+
+	if logoutToken == "" {
+		return nil, ResponseMeta{}, ErrEmptyToken
+	}
+
+	o := newCallOptions(opts)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
 	form := url.Values{}
 	form.Add("logout_token", logoutToken)
-	form.Add("app_id", api.id)
-	form.Add("app_secret", api.secret)
+	api.addCredentials(form)
+	o.addExtraParams(form)
 
-	lr := LogoutResponse{}
-	if request, err := api.NewRequest("POST", "logout", form); err != nil {
-		return nil, err
-	} else if err := api.Do(request, &lr); err != nil {
-		return nil, err
+	if request, err := api.NewRequestContext(ctx, "POST", "logout", form); err != nil {
+		return nil, ResponseMeta{}, err
+	} else if lr, meta, err := do[LogoutResponse](api, request); err != nil {
+		return nil, meta, err
 	} else {
-		return &lr, nil
+		lr.Source = o.logoutSource
+		api.log.Debugf("clef logout clef_id=%d source=%s", lr.ID, lr.Source)
+		return lr, meta, nil
 	}
 }
 
+// ClefID identifies a Clef user
+type ClefID int
+
+// String returns the decimal representation of the Clef ID
+func (id ClefID) String() string {
+	return strconv.Itoa(int(id))
+}
+
 // InfoStruct contains the info about the logged in user
 type InfoStruct struct {
-	ID          int    `json:"id"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	PhoneNumber string `json:"phone_number"`
-	Email       string `json:"email"`
+	ID          ClefID `json:"id"`
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	Email       string `json:"email,omitempty"`
 }
 
 // InfoResponse contains the response of the Info call
 type InfoResponse struct {
 	Info    *InfoStruct `json:"info"`
+	Scopes  []Scope     `json:"scopes"`
 	Success bool        `json:"success"`
+
+	// RefreshedToken is the new access token when the access token expired
+	// and has been refreshed, see WithAutoRefresh
+	RefreshedToken string `json:"-"`
+}
+
+func (ir *InfoResponse) succeeded() bool { return ir.Success }
+
+// HasScope returns true if the access token has been granted scope s
+func (ir *InfoResponse) HasScope(s Scope) bool {
+	for _, scope := range ir.Scopes {
+		if scope == s {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Info will return the info about the logged in Clef user
-func (api *API) Info(accessToken string) (*InfoResponse, error) {
-	io := InfoResponse{}
-	if request, err := api.NewRequest("GET", "info?access_token="+accessToken, nil); err != nil {
+func (api *API) Info(accessToken string, opts ...CallOption) (*InfoResponse, error) {
+	return api.InfoContext(context.Background(), accessToken, opts...)
+}
+
+// InfoContext will return the info about the logged in Clef user. The
+// request is cancelled when ctx is done.
+func (api *API) InfoContext(ctx context.Context, accessToken string, opts ...CallOption) (*InfoResponse, error) {
+	ir, _, err := api.InfoWithMeta(ctx, accessToken, opts...)
+	return ir, err
+}
+
+// InfoWithMeta will return the info about the logged in Clef user and the
+// response metadata. Responses served from the info cache have empty
+// metadata. Calls with extra params (see WithExtraParams) bypass the info
+// cache and single flight.
+func (api *API) InfoWithMeta(ctx context.Context, accessToken string, opts ...CallOption) (*InfoResponse, ResponseMeta, error) {
+	if err := api.ready(); err != nil {
+		return nil, ResponseMeta{}, err
+	}
+
+	if accessToken == "" {
+		return nil, ResponseMeta{}, ErrEmptyToken
+	}
+
+	o := newCallOptions(opts)
+
+	if api.infoCache != nil && len(o.extraParams) == 0 {
+		if ir, ok := api.infoCache.get(accessToken, api.clock.Now()); ok {
+			return ir, ResponseMeta{}, nil
+		}
+	}
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	ir, meta, err := api.info(ctx, accessToken, o)
+	if api.refresh == nil || !IsExpiredTokenError(err) {
+		return ir, meta, err
+	}
+
+	// refresh the expired token and try once more
+	refreshed, err := api.refresh(accessToken)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	if ir, meta, err = api.info(ctx, refreshed, o); err != nil {
+		return nil, meta, err
+	}
+
+	ir.RefreshedToken = refreshed
+	return ir, meta, nil
+}
+
+func (api *API) info(ctx context.Context, accessToken string, o callOptions) (*InfoResponse, ResponseMeta, error) {
+	if api.flights == nil || len(o.extraParams) > 0 {
+		return api.fetchInfo(ctx, accessToken, o)
+	}
+
+	return api.flights.do(ctx, accessToken, func() (*InfoResponse, ResponseMeta, error) {
+		return api.fetchInfo(ctx, accessToken, o)
+	})
+}
+
+func (api *API) fetchInfo(ctx context.Context, accessToken string, o callOptions) (*InfoResponse, ResponseMeta, error) {
+	if request, err := api.newInfoRequest(ctx, accessToken, o); err != nil {
+		return nil, ResponseMeta{}, err
+	} else if ir, meta, err := do[InfoResponse](api, request); err != nil {
+		return nil, meta, err
+	} else {
+		if api.infoCache != nil && len(o.extraParams) == 0 {
+			api.infoCache.set(accessToken, ir, api.clock.Now())
+		}
+
+		return ir, meta, nil
+	}
+}
+
+// AuthorizeAndInfo exchanges an OAuth code for an OAuth token and returns
+// the info about the user it belongs to. Info isn't called when Authorize
+// fails. The call options apply to Authorize, except for a timeout set using
+// WithTimeout, which covers both calls.
+func (api *API) AuthorizeAndInfo(ctx context.Context, code string, opts ...CallOption) (*AuthorizeResponse, *InfoResponse, error) {
+	ctx, cancel := newCallOptions(opts).context(ctx)
+	defer cancel()
+
+	ar, err := api.AuthorizeContext(ctx, code, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ir, err := api.InfoContext(ctx, ar.AccessToken)
+	if err != nil {
+		return ar, nil, err
+	}
+
+	return ar, ir, nil
+}
+
+// InfoRaw returns the undecoded response of the Info call, giving access to
+// fields not modeled by InfoResponse.
+func (api *API) InfoRaw(accessToken string, opts ...CallOption) (json.RawMessage, error) {
+	return api.InfoRawContext(context.Background(), accessToken, opts...)
+}
+
+// InfoRawContext returns the undecoded response of the Info call. The
+// request is cancelled when ctx is done.
+func (api *API) InfoRawContext(ctx context.Context, accessToken string, opts ...CallOption) (json.RawMessage, error) {
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+
+	if accessToken == "" {
+		return nil, ErrEmptyToken
+	}
+
+	o := newCallOptions(opts)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	if request, err := api.newInfoRequest(ctx, accessToken, o); err != nil {
 		return nil, err
-	} else if err := api.Do(request, &io); err != nil {
+	} else if raw, _, err := do[json.RawMessage](api, request); err != nil {
 		return nil, err
 	} else {
-		return &io, nil
+		return *raw, nil
 	}
 }
 
+func (api *API) newInfoRequest(ctx context.Context, accessToken string, o callOptions) (*http.Request, error) {
+	query := url.Values{}
+	query.Set("access_token", accessToken)
+	o.addExtraParams(query)
+
+	return api.NewRequestContext(ctx, "GET", "info?"+query.Encode(), nil)
+}
+
 // SwagRequest contains the request for the Swag API call
 type SwagRequest struct {
-	AppID        string `json:"app_id"`
-	AppSecret    string `json:"app_secret"`
+	// Deprecated: AppID is ignored, the application id of the API is used.
+	AppID string `json:"app_id,omitempty"`
+	// Deprecated: AppSecret is ignored, the application secret of the API
+	// is used.
+	AppSecret string `json:"app_secret,omitempty"`
+
 	Name         string `json:"name"`
 	Email        string `json:"email"`
 	AddressLine1 string `json:"address_line_1"`
-	AddressLine2 string `json:"address_line_2"`
+	AddressLine2 string `json:"address_line_2,omitempty"`
 	City         string `json:"city"`
-	ZipCode      string `json:"zip_code"`
-	State        string `json:"state"`
+	ZipCode      string `json:"zip_code,omitempty"`
+	State        string `json:"state,omitempty"`
 	Country      string `json:"country"`
+
+	// DryRun validates the request and returns the form that would be
+	// sent, without placing an order
+	DryRun bool `json:"-"`
 }
 
 // SwagResponse contains the response for the Swag API call
 type SwagResponse struct {
 	Message bool `json:"message"`
 	Success bool `json:"success"`
+
+	// Form is the form that would have been sent for a dry run, with the
+	// application secret redacted
+	Form url.Values `json:"-"`
 }
 
+func (sr *SwagResponse) succeeded() bool { return sr.Success }
+
 // Swag can be call to order swag items
-func (api *API) Swag(req *SwagRequest) (*SwagResponse, error) {
+func (api *API) Swag(req *SwagRequest, opts ...CallOption) (*SwagResponse, error) {
+	return api.SwagContext(context.Background(), req, opts...)
+}
+
+// SwagContext can be call to order swag items. The request is cancelled
+// when ctx is done.
+func (api *API) SwagContext(ctx context.Context, req *SwagRequest, opts ...CallOption) (*SwagResponse, error) {
+	sr, _, err := api.SwagWithMeta(ctx, req, opts...)
+	return sr, err
+}
+
+// SwagWithMeta can be call to order swag items and returns the response
+// metadata as well.
+func (api *API) SwagWithMeta(ctx context.Context, req *SwagRequest, opts ...CallOption) (*SwagResponse, ResponseMeta, error) {
+	if err := api.ready(); err != nil {
+		return nil, ResponseMeta{}, err
+	}
+
+	if req == nil {
+		return nil, ResponseMeta{}, errors.New("clef: nil swag request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, ResponseMeta{}, err
+	}
+
+	o := newCallOptions(opts)
+
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	idempotencyKey := o.idempotencyKey
+	if idempotencyKey == "" {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return nil, ResponseMeta{}, err
+		}
+
+		idempotencyKey = key
+	}
+
 	form := url.Values{}
-	form.Add("app_id", req.AppID)
-	form.Add("app_secret", req.AppSecret)
+	api.addCredentials(form)
 	form.Add("name", req.Name)
 	form.Add("email", req.Email)
 	form.Add("address_line_1", req.AddressLine1)
@@ -221,62 +715,280 @@ func (api *API) Swag(req *SwagRequest) (*SwagResponse, error) {
 	form.Add("zip_code", req.ZipCode)
 	form.Add("state", req.State)
 	form.Add("country", req.Country)
+	o.addExtraParams(form)
 
-	sr := SwagResponse{}
-	if request, err := api.NewRequest("POST", "swag", form); err != nil {
-		return nil, err
-	} else if err := api.Do(request, &sr); err != nil {
-		return nil, err
+	if req.DryRun {
+		form.Set("app_secret", "REDACTED")
+		return &SwagResponse{Success: true, Form: form}, ResponseMeta{}, nil
+	}
+
+	if request, err := api.NewRequestContext(ctx, "POST", "swag", form); err != nil {
+		return nil, ResponseMeta{}, err
 	} else {
-		return &sr, nil
+		// the key is kept when the request is retried, so the server can
+		// dedupe the order
+		request.Header.Set("Idempotency-Key", idempotencyKey)
+		return do[SwagResponse](api, request)
 	}
 }
 
+// ResponseMeta contains the metadata of a Clef API response
+type ResponseMeta struct {
+	StatusCode int
+	Header     http.Header
+	Duration   time.Duration
+}
+
+// versionURL returns the url the endpoints are relative to, the base url or
+// <base url>/<version>/ when an API version has been set
+func (api *API) versionURL() *url.URL {
+	if api.apiVersion == "" {
+		return api.baseURL
+	}
+
+	return api.baseURL.ResolveReference(&url.URL{Path: api.apiVersion + "/"})
+}
+
 // NewRequest returns a raw Clef API request
 func (api *API) NewRequest(method, urlStr string, form url.Values) (*http.Request, error) {
+	return api.NewRequestContext(context.Background(), method, urlStr, form)
+}
+
+// NewRequestContext returns a raw Clef API request bound to ctx
+func (api *API) NewRequestContext(ctx context.Context, method, urlStr string, form url.Values) (*http.Request, error) {
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+
 	rel, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
 	}
 
-	u := api.baseURL.ResolveReference(rel)
+	u := api.versionURL().ResolveReference(rel)
 
-	req, err := http.NewRequest(method, u.String(), strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if api.accept != "" {
+		req.Header.Set("Accept", api.accept)
+	}
+
+	if api.userAgent != "" {
+		req.Header.Set("User-Agent", api.userAgent)
+	}
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		req.Header.Set("X-Request-ID", id)
+	}
+
 	return req, nil
 }
 
-// Do executes a raw Clef API request
+// Do executes a raw Clef API request. Idempotent requests are retried when
+// configured using WithRetry.
 func (api *API) Do(req *http.Request, v interface{}) error {
-	if dump, err := httputil.DumpRequestOut(req, true); err == nil {
-		log.Debugf("Request:\n\n%s\n", string(dump))
+	if err := api.ready(); err != nil {
+		return err
 	}
 
-	if resp, err := api.Client.Do(req); err != nil {
-		return err
+	_, err := api.doMeta(req, v)
+	return err
+}
+
+// decode decodes the response body into v
+func (api *API) decode(body []byte, v interface{}) error {
+	if api.codec != nil {
+		return api.codec.Decode(bytes.NewReader(body), v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if api.strictDecoding {
+		dec.DisallowUnknownFields()
+	}
+
+	return dec.Decode(v)
+}
+
+// do executes a raw Clef API request and returns the decoded response.
+// Errors are wrapped with the name of the endpoint.
+func do[T any](api *API, req *http.Request) (*T, ResponseMeta, error) {
+	v := new(T)
+
+	var de *decodeError
+	if meta, err := api.doMeta(req, v); errors.As(err, &de) {
+		// already names the endpoint
+		return nil, meta, err
+	} else if err != nil {
+		return nil, meta, &endpointError{endpoint: api.endpoint(req), err: err}
 	} else {
-		if dump, err := httputil.DumpResponse(resp, true); err == nil {
-			log.Debugf("Response:\n\n%s\n", string(dump))
+		return v, meta, nil
+	}
+}
+
+// doMeta executes req, retrying when allowed, and returns the metadata of
+// the last attempt
+func (api *API) doMeta(req *http.Request, v interface{}) (ResponseMeta, error) {
+	for attempt := 1; ; attempt++ {
+		if err := api.breaker.allow(api.clock.Now()); err != nil {
+			return ResponseMeta{}, err
+		}
+
+		meta, err := api.attempt(req, v)
+		api.breaker.record(err, api.clock.Now())
+
+		if err == nil || !api.shouldRetry(req, err, attempt) {
+			return meta, err
 		}
 
+		api.log.Debugf("clef retry method=%s endpoint=%s attempt=%d error=%q", req.Method, api.endpoint(req), attempt, redactError(err))
+
+		if err := api.waitRetry(req.Context(), api.retryDelay(err, attempt)); err != nil {
+			return meta, err
+		}
+
+		if req, err = rewind(req); err != nil {
+			return meta, err
+		}
+	}
+}
+
+// ready returns ErrNotInitialized when api hasn't been created using New, so
+// a nil or zero value API returns errors instead of panicking.
+func (api *API) ready() error {
+	if api == nil || api.baseURL == nil {
+		return ErrNotInitialized
+	}
+
+	return nil
+}
+
+// client returns the http client used to send requests. Redirects are not
+// followed unless the client has its own redirect policy, as following them
+// would silently turn POST requests into GET requests.
+func (api *API) client() *http.Client {
+	base := api.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+
+	c := *base
+	if c.CheckRedirect == nil {
+		c.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return &c
+}
+
+// send executes req once
+func (api *API) send(req *http.Request, v interface{}) (ResponseMeta, error) {
+	release, err := api.acquire(req.Context())
+	if err != nil {
+		return ResponseMeta{}, err
+	}
+
+	defer release()
+
+	if err := api.runRequestHooks(req); err != nil {
+		return ResponseMeta{}, err
+	}
+
+	api.dumpRequest(req)
+
+	if api.inspector != nil {
+		api.inspector(req)
+	}
+
+	start := time.Now()
+	if resp, err := api.client().Do(req); err != nil {
+		meta := ResponseMeta{Duration: time.Since(start)}
+		api.observe(req, meta)
+		api.logRequest(req, meta, err)
+		return meta, err
+	} else {
 		defer resp.Body.Close()
 
+		meta := ResponseMeta{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Duration:   time.Since(start),
+		}
+
+		api.observe(req, meta)
+		api.logRequest(req, meta, nil)
+
+		if err := api.runResponseHooks(resp); err != nil {
+			return meta, err
+		}
+
+		if rl, ok := parseRateLimit(resp.Header); ok {
+			api.setRateLimit(rl)
+		}
+
+		api.updateServerVersion(resp.Header)
+
 		var r io.Reader = resp.Body
 
-		if resp.StatusCode != http.StatusOK {
-			err := Error{}
-			json.NewDecoder(r).Decode(&err)
-			return &err
+		if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			gr, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				return meta, err
+			}
+
+			defer gr.Close()
+			r = gr
 		}
 
-		if err := json.NewDecoder(r).Decode(&v); err != nil {
-			return err
+		body, err := io.ReadAll(io.LimitReader(r, api.maxResponseBytes+1))
+		if err != nil {
+			return meta, err
+		} else if int64(len(body)) > api.maxResponseBytes {
+			return meta, ErrResponseTooLarge
 		}
 
-		return nil
+		api.dumpResponse(resp, body)
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			return meta, &Error{
+				InternalError: "clef: unexpected redirect to " + resp.Header.Get("Location"),
+				StatusCode:    resp.StatusCode,
+			}
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return meta, newStatusError(resp, body, api.clock.Now())
+		}
+
+		if resp.StatusCode == http.StatusNoContent {
+			return meta, nil
+		}
+
+		if err := api.decode(body, v); err != nil {
+			return meta, newDecodeError(api.endpoint(req), body, err)
+		}
+
+		// the api reports some failures with a 200 response
+		if s, ok := v.(successer); ok && !s.succeeded() {
+			err := Error{}
+			json.Unmarshal(body, &err)
+			err.StatusCode = resp.StatusCode
+
+			if err.InternalError == "" && err.Message != "" {
+				err.InternalError = "clef: " + err.Message
+			} else if err.InternalError == "" {
+				err.InternalError = "clef: request was not successful"
+			}
+
+			return meta, &err
+		}
+
+		return meta, nil
 	}
 }
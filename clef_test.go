@@ -0,0 +1,857 @@
+package clef
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestAPI returns an API pointed at a test server serving h
+func newTestAPI(tb testing.TB, h http.HandlerFunc, opts ...Option) *API {
+	tb.Helper()
+
+	s := httptest.NewServer(h)
+	tb.Cleanup(s.Close)
+
+	opts = append([]Option{WithBaseURL(s.URL + "/api/")}, opts...)
+
+	api, err := New("app-id", "app-secret", opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return api
+}
+
+// jsonHandler returns a handler responding with status and body
+func jsonHandler(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+const infoOK = `{"success":true,"info":{"id":1}}`
+
+// recordingAPI returns an API whose test server records the form (or query)
+// of the last request and responds with body
+func recordingAPI(t *testing.T, body string, opts ...Option) (*API, *url.Values) {
+	got := &url.Values{}
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		*got = r.Form
+		jsonHandler(http.StatusOK, body)(w, r)
+	}, opts...)
+
+	return api, got
+}
+
+func TestDefaultBaseURL(t *testing.T) {
+	api, err := New("app-id", "app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := api.NewRequest("POST", "authorize", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := req.URL.String(), "https://clef.io/api/authorize"; got != want {
+		t.Errorf("url = %q, want %q", got, want)
+	}
+}
+
+func TestAPIVersionPath(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "/api/info"},
+		{"v2", []Option{WithAPIVersion("v2")}, "/api/v2/info"},
+		{"slashes", []Option{WithAPIVersion("/v2/")}, "/api/v2/info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+
+			api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1}}`)(w, r)
+			}, tt.opts...)
+
+			if _, err := api.Info("token"); err != nil {
+				t.Fatal(err)
+			}
+
+			if path != tt.want {
+				t.Errorf("path = %q, want %q", path, tt.want)
+			}
+		})
+	}
+}
+
+func TestMalformedSuccessBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		excerpt string
+		check   func(error) bool
+	}{
+		{"truncated", `{"success":true,"access_token":"SECRETTOKENVALUE`, "success", func(err error) bool {
+			return errors.Is(err, io.ErrUnexpectedEOF)
+		}},
+		{"html", `<html>bad gateway</html>`, "<html>bad gateway", func(err error) bool {
+			var se *json.SyntaxError
+			return errors.As(err, &se)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, jsonHandler(http.StatusOK, tt.body))
+
+			_, err := api.Authorize("code")
+			if err == nil {
+				t.Fatal("Authorize() = nil error, want decode error")
+			}
+
+			msg := err.Error()
+			if !strings.HasPrefix(msg, "clef: failed to decode authorize response: ") {
+				t.Errorf("error = %q, want decode error prefix", msg)
+			}
+
+			if strings.Count(msg, "clef:") != 1 {
+				t.Errorf("error = %q, want a single prefix", msg)
+			}
+
+			if strings.Contains(msg, "SECRETTOKENVALUE") {
+				t.Errorf("error = %q, contains the access token", msg)
+			}
+
+			if !strings.Contains(msg, tt.excerpt) {
+				t.Errorf("error = %q, want it to include the start of the body", msg)
+			}
+
+			if !tt.check(err) {
+				t.Errorf("error = %#v, doesn't wrap the decoder error", err)
+			}
+		})
+	}
+}
+
+// validSwag returns a swag request that passes validation
+func validSwag() *SwagRequest {
+	return &SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"}
+}
+
+// restoreDefault restores the default instance after the test
+func restoreDefault(t *testing.T) {
+	old := defaultAPI.Load()
+	t.Cleanup(func() { defaultAPI.Store(old) })
+}
+
+func TestInfoEscapesAccessToken(t *testing.T) {
+	const token = "a&foo=bar#frag+%20 ="
+
+	api, got := recordingAPI(t, infoOK)
+	if _, err := api.Info(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := got.Get("access_token"); v != token {
+		t.Errorf("access_token = %q, want %q", v, token)
+	}
+
+	if _, ok := (*got)["foo"]; ok {
+		t.Errorf("token leaked into other parameters: %v", *got)
+	}
+}
+
+func TestUnsuccessfulResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(api *API) error
+	}{
+		{"authorize", func(api *API) error { _, err := api.Authorize("code"); return err }},
+		{"logout", func(api *API) error { _, err := api.Logout("token"); return err }},
+		{"info", func(api *API) error { _, err := api.Info("token"); return err }},
+		{"swag", func(api *API) error { _, err := api.Swag(validSwag()); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, jsonHandler(http.StatusOK, `{"success":false,"error":"Something went wrong."}`))
+
+			err := tt.call(api)
+			if err == nil {
+				t.Fatal("error = nil, want an error for success false")
+			}
+
+			if !strings.Contains(err.Error(), "Something went wrong.") {
+				t.Errorf("error = %q, want it to contain the message", err)
+			}
+
+			var e *Error
+			if !errors.As(err, &e) || e.StatusCode != http.StatusOK {
+				t.Errorf("error = %#v, want *Error with status 200", err)
+			}
+		})
+	}
+}
+
+func TestDefaultInstanceRace(t *testing.T) {
+	restoreDefault(t)
+
+	s := httptest.NewServer(jsonHandler(http.StatusOK, infoOK))
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			if err := InitializeWithOptions("app-id", "app-secret", WithBaseURL(s.URL+"/api/")); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			// not initialized yet is fine, racing is not
+			if _, err := Info("token"); err != nil && !errors.Is(err, ErrNotInitialized) {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestInitializeOnce(t *testing.T) {
+	restoreDefault(t)
+
+	initOnceMu.Lock()
+	initOnceDone = false
+	initOnceMu.Unlock()
+
+	t.Cleanup(func() {
+		initOnceMu.Lock()
+		initOnceDone = false
+		initOnceMu.Unlock()
+	})
+
+	if err := InitializeOnce("app-id", "app-secret", WithBaseURL("://invalid")); err == nil {
+		t.Fatal("InitializeOnce() with invalid options = nil error")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := InitializeOnce("app-id", "app-secret"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	first, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InitializeOnce("other-id", "other-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if api, _ := Default(); api != first || api.appID() != "app-id" {
+		t.Error("InitializeOnce replaced the default instance")
+	}
+}
+
+func TestNonJSONErrorBody(t *testing.T) {
+	long := "<html>" + strings.Repeat("x", 1000) + "</html>"
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"html", "<html>Bad Gateway</html>", "clef: unexpected status 502: <html>Bad Gateway</html>"},
+		{"empty", "", "clef: unexpected status 502: "},
+		{"truncated", long, "clef: unexpected status 502: " + long[:maxErrorBody]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusBadGateway)
+				io.WriteString(w, tt.body)
+			})
+
+			_, err := api.Info("token")
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("error = %v, want *Error", err)
+			}
+
+			if e.StatusCode != http.StatusBadGateway || e.Error() != tt.want {
+				t.Errorf("error = %d %q, want 502 %q", e.StatusCode, e.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSwagUsesAPICredentials(t *testing.T) {
+	api, got := recordingAPI(t, `{"success":true}`)
+
+	req := validSwag()
+	req.AppID = "other-id"
+	req.AppSecret = "other-secret"
+
+	if _, err := api.Swag(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Get("app_id") != "app-id" || got.Get("app_secret") != "app-secret" {
+		t.Errorf("credentials = %q/%q, want the credentials of the API", got.Get("app_id"), got.Get("app_secret"))
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		io.WriteString(gw, `{"success":true,"info":{"id":42,"email":"jane@example.com"}}`)
+		gw.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	})
+
+	ir, err := api.Info("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ir.Info.ID != 42 || ir.Info.Email != "jane@example.com" {
+		t.Errorf("info = %+v, want the decompressed response", ir.Info)
+	}
+}
+
+func TestRedirectIsRejected(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://elsewhere.example.com/api/authorize", http.StatusFound)
+	})
+
+	_, err := api.Authorize("code")
+
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusFound {
+		t.Fatalf("error = %v, want *Error with status 302", err)
+	}
+
+	if !strings.Contains(err.Error(), "unexpected redirect to https://elsewhere.example.com/api/authorize") {
+		t.Errorf("error = %q, want it to name the redirect target", err)
+	}
+}
+
+func TestSuccessStatusRange(t *testing.T) {
+	t.Run("201", func(t *testing.T) {
+		api := newTestAPI(t, jsonHandler(http.StatusCreated, `{"success":true}`))
+
+		sr, err := api.Swag(validSwag())
+		if err != nil || !sr.Success {
+			t.Errorf("Swag() = %+v, %v, want success", sr, err)
+		}
+	})
+
+	t.Run("204", func(t *testing.T) {
+		api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		req, err := api.NewRequest("POST", "logout", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var v map[string]interface{}
+		if err := api.Do(req, &v); err != nil || v != nil {
+			t.Errorf("Do() = %v, %v, want no error and nothing decoded", v, err)
+		}
+	})
+}
+
+func TestJSONOmitsEmptyOptionalFields(t *testing.T) {
+	b, err := json.Marshal(InfoStruct{ID: 1, Email: "jane@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(b), `{"id":1,"email":"jane@example.com"}`; got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+
+	// the form still carries every field
+	api, got := recordingAPI(t, `{"success":true}`)
+	if _, err := api.Swag(validSwag()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"address_line_2", "zip_code", "state"} {
+		if v, ok := (*got)[k]; !ok || len(v) != 1 || v[0] != "" {
+			t.Errorf("form field %s = %q, want a single empty value", k, v)
+		}
+	}
+}
+
+func TestAuthorizeExpiry(t *testing.T) {
+	clock := newFakeClock()
+	api := newTestAPI(t, jsonHandler(http.StatusOK, `{"success":true,"access_token":"t","refresh_token":"r","expires_in":3600}`), WithClock(clock))
+
+	ar, err := api.Authorize("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := clock.Now().Add(time.Hour); !ar.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %s, want %s", ar.ExpiresAt, want)
+	}
+
+	if ar.RefreshToken != "r" {
+		t.Errorf("RefreshToken = %q, want r", ar.RefreshToken)
+	}
+}
+
+func TestTimeUntilExpiry(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ar   AuthorizeResponse
+		want time.Duration
+	}{
+		{"expired", AuthorizeResponse{ExpiresAt: now.Add(-time.Minute)}, -time.Minute},
+		{"expires now", AuthorizeResponse{ExpiresAt: now}, 0},
+		{"about to expire", AuthorizeResponse{ExpiresAt: now.Add(time.Second)}, time.Second},
+		{"fresh", AuthorizeResponse{ExpiresAt: now.Add(time.Hour)}, time.Hour},
+		{"never expires", AuthorizeResponse{}, time.Duration(1<<63 - 1)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ar.TimeUntilExpiry(now); got != tt.want {
+			t.Errorf("%s: TimeUntilExpiry() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAutoRefresh(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") == "old" {
+			jsonHandler(http.StatusUnauthorized, `{"error":"Token expired.","message":"Token expired."}`)(w, r)
+			return
+		}
+
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}, WithAutoRefresh(func(expired string) (string, error) {
+		if expired != "old" {
+			t.Errorf("refresh called with %q, want old", expired)
+		}
+
+		return "new", nil
+	}))
+
+	ir, err := api.Info("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ir.RefreshedToken != "new" {
+		t.Errorf("RefreshedToken = %q, want new", ir.RefreshedToken)
+	}
+
+	// a failing refresh returns its error
+	api = newTestAPI(t, jsonHandler(http.StatusUnauthorized, `{"error":"Token expired.","message":"Token expired."}`), WithAutoRefresh(func(string) (string, error) {
+		return "", errors.New("no refresh token")
+	}))
+
+	if _, err := api.Info("old"); err == nil || err.Error() != "no refresh token" {
+		t.Errorf("error = %v, want the refresh error", err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	body := `{"success":true,"info":{"id":1},"new_field":true}`
+
+	if _, err := newTestAPI(t, jsonHandler(http.StatusOK, body)).Info("token"); err != nil {
+		t.Errorf("lenient Info() = %v, want no error", err)
+	}
+
+	if _, err := newTestAPI(t, jsonHandler(http.StatusOK, body), WithStrictDecoding()).Info("token"); err == nil || !strings.Contains(err.Error(), "new_field") {
+		t.Errorf("strict Info() = %v, want an unknown field error", err)
+	}
+}
+
+func TestErrorsNameTheEndpoint(t *testing.T) {
+	api := newTestAPI(t, jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`))
+
+	tests := []struct {
+		want string
+		call func() error
+	}{
+		{"clef: authorize request failed: Invalid token.", func() error { _, err := api.Authorize("code"); return err }},
+		{"clef: logout request failed: Invalid token.", func() error { _, err := api.Logout("token"); return err }},
+		{"clef: info request failed: Invalid token.", func() error { _, err := api.Info("token"); return err }},
+		{"clef: swag request failed: Invalid token.", func() error { _, err := api.Swag(validSwag()); return err }},
+	}
+
+	for _, tt := range tests {
+		err := tt.call()
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("error = %v, want %q", err, tt.want)
+		}
+
+		var e *Error
+		if !errors.As(err, &e) || e.StatusCode != http.StatusForbidden {
+			t.Errorf("errors.As(%v) failed to unwrap *Error", err)
+		}
+
+		if !IsInvalidTokenError(err) {
+			t.Errorf("IsInvalidTokenError(%v) = false", err)
+		}
+	}
+}
+
+func TestErrorsArePrefixedOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		opts []Option
+		want string
+	}{
+		{"status", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }, nil, "clef: info request failed: unexpected status 502: "},
+		{"too large", jsonHandler(http.StatusOK, infoOK), []Option{WithMaxResponseBytes(4)}, "clef: info request failed: response body too large"},
+	}
+
+	for _, tt := range tests {
+		api := newTestAPI(t, tt.h, tt.opts...)
+
+		if _, err := api.Info("token"); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	api := newTestAPI(t, jsonHandler(http.StatusBadGateway, ""), WithCircuitBreaker(1, time.Hour))
+	api.Info("token")
+
+	want := "clef: info request failed: circuit breaker open"
+	if _, err := api.Info("token"); err == nil || err.Error() != want {
+		t.Errorf("circuit open: error = %v, want %q", err, want)
+	}
+}
+
+func TestNotInitializedAPI(t *testing.T) {
+	for _, api := range []*API{nil, {}} {
+		calls := map[string]func() error{
+			"authorize": func() error { _, err := api.Authorize("code"); return err },
+			"logout":    func() error { _, err := api.Logout("token"); return err },
+			"info":      func() error { _, err := api.Info("token"); return err },
+			"swag":      func() error { _, err := api.Swag(validSwag()); return err },
+			"health":    func() error { return api.HealthCheck(context.Background()) },
+			"request": func() error {
+				_, err := api.NewRequest("GET", "info", nil)
+				return err
+			},
+		}
+
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, ErrNotInitialized) {
+				t.Errorf("%s on %#v: error = %v, want ErrNotInitialized", name, api, err)
+			}
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var header string
+	var buf bytes.Buffer
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Request-ID")
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}, WithDebugWriter(&buf))
+
+	ctx := ContextWithRequestID(context.Background(), "req-123")
+	if _, err := api.InfoContext(ctx, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if header != "req-123" {
+		t.Errorf("X-Request-ID = %q, want req-123", header)
+	}
+
+	if !strings.Contains(buf.String(), `request_id="req-123"`) {
+		t.Errorf("log doesn't contain the request id:\n%s", buf.String())
+	}
+}
+
+func TestSwagDryRun(t *testing.T) {
+	var requests int32
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		jsonHandler(http.StatusOK, `{"success":true}`)(w, r)
+	})
+
+	req := validSwag()
+	req.DryRun = true
+
+	sr, err := api.Swag(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("requests = %d, want no order to be placed", n)
+	}
+
+	if sr.Form.Get("name") != "Jane" || sr.Form.Get("app_secret") != "REDACTED" {
+		t.Errorf("form = %v, want the swag fields with the secret redacted", sr.Form)
+	}
+
+	// dry runs are validated as well
+	req.Email = ""
+	if _, err := api.Swag(req); err == nil {
+		t.Error("invalid dry run = nil error")
+	}
+}
+
+func TestWithRedirectURL(t *testing.T) {
+	api, got := recordingAPI(t, `{"success":true,"access_token":"t"}`)
+
+	if _, err := api.Authorize("code"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := (*got)["redirect_url"]; ok {
+		t.Errorf("redirect_url sent without WithRedirectURL: %v", *got)
+	}
+
+	if _, err := api.Authorize("code", WithRedirectURL("myapp://callback")); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := got.Get("redirect_url"); v != "myapp://callback" {
+		t.Errorf("redirect_url = %q, want myapp://callback", v)
+	}
+}
+
+func TestAuthorizeAndInfo(t *testing.T) {
+	var infoRequests int32
+
+	handler := func(authorize http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/authorize") {
+				authorize(w, r)
+				return
+			}
+
+			atomic.AddInt32(&infoRequests, 1)
+			if r.URL.Query().Get("access_token") != "t" {
+				t.Errorf("info called with %q, want the authorized token", r.URL.Query().Get("access_token"))
+			}
+
+			jsonHandler(http.StatusOK, infoOK)(w, r)
+		}
+	}
+
+	api := newTestAPI(t, handler(jsonHandler(http.StatusOK, `{"success":true,"access_token":"t"}`)))
+
+	ar, ir, err := api.AuthorizeAndInfo(context.Background(), "code")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ar.AccessToken != "t" || ir.Info.ID != 1 {
+		t.Errorf("AuthorizeAndInfo() = %+v, %+v", ar, ir)
+	}
+
+	// a failing authorize doesn't call info
+	atomic.StoreInt32(&infoRequests, 0)
+	api = newTestAPI(t, handler(jsonHandler(http.StatusBadRequest, `{"error":"Invalid code."}`)))
+
+	ar, ir, err = api.AuthorizeAndInfo(context.Background(), "code")
+	if err == nil || ar != nil || ir != nil {
+		t.Errorf("AuthorizeAndInfo() = %v, %v, %v, want only an error", ar, ir, err)
+	}
+
+	if n := atomic.LoadInt32(&infoRequests); n != 0 {
+		t.Errorf("info requests = %d, want 0", n)
+	}
+}
+
+func TestAuthorizeAndInfoCallOptions(t *testing.T) {
+	var infoQuery url.Values
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+
+		if strings.HasSuffix(r.URL.Path, "/authorize") {
+			jsonHandler(http.StatusOK, `{"success":true,"access_token":"t"}`)(w, r)
+			return
+		}
+
+		infoQuery = r.URL.Query()
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	})
+
+	// extra params are only sent to authorize
+	if _, _, err := api.AuthorizeAndInfo(context.Background(), "code", WithExtraParams(map[string]string{"foo": "bar"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if infoQuery.Get("foo") != "" {
+		t.Errorf("info query = %v, want no extra params", infoQuery)
+	}
+
+	// the timeout covers both calls
+	_, _, err := api.AuthorizeAndInfo(context.Background(), "code", WithTimeout(150*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestAcceptHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "application/json"},
+		{"override", []Option{WithAccept("application/vnd.clef+json")}, "application/vnd.clef+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accepts []string
+			var mu sync.Mutex
+
+			api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				accepts = append(accepts, r.Header.Get("Accept"))
+				mu.Unlock()
+
+				jsonHandler(http.StatusOK, `{"success":true,"access_token":"t","info":{"id":1}}`)(w, r)
+			}, tt.opts...)
+
+			api.Authorize("code")
+			api.Logout("token")
+			api.Info("token")
+			api.Swag(validSwag())
+			api.HealthCheck(context.Background())
+
+			if len(accepts) != 5 {
+				t.Fatalf("requests = %d, want 5", len(accepts))
+			}
+
+			for _, accept := range accepts {
+				if accept != tt.want {
+					t.Errorf("Accept = %q, want %q", accept, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogoutSource(t *testing.T) {
+	logger := &recordingLogger{}
+	api := newTestAPI(t, jsonHandler(http.StatusOK, `{"success":true,"clef_id":42}`), WithLogger(logger))
+
+	lr, err := api.Logout("token")
+	if err != nil || lr.Source != SourceInteractive {
+		t.Errorf("Logout() = %+v, %v, want source %s", lr, err, SourceInteractive)
+	}
+
+	lr, err = api.Logout("token", WithLogoutSource(SourceWebhook))
+	if err != nil || lr.Source != SourceWebhook {
+		t.Errorf("Logout() = %+v, %v, want source %s", lr, err, SourceWebhook)
+	}
+
+	// the webhook handler logs out with SourceWebhook
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader("logout_token=lt"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	api.LogoutWebhookHandler(func(ClefID) {})(httptest.NewRecorder(), r)
+
+	want := []string{
+		"clef logout clef_id=42 source=interactive",
+		"clef logout clef_id=42 source=webhook",
+		"clef logout clef_id=42 source=webhook",
+	}
+
+	var got []string
+	for _, msg := range logger.debug {
+		if strings.HasPrefix(msg, "clef logout ") {
+			got = append(got, msg)
+		}
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestEmptyCodeAndToken(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"authorize", func() error { _, err := api.Authorize(""); return err }, ErrEmptyCode},
+		{"logout", func() error { _, err := api.Logout(""); return err }, ErrEmptyToken},
+		{"info", func() error { _, err := api.Info(""); return err }, ErrEmptyToken},
+	}
+
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
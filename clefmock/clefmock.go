@@ -0,0 +1,149 @@
+// Package clefmock provides a fake Clef API server for testing code that
+// depends on goclef without hitting clef.io.
+//
+//	s := clefmock.NewServer()
+//	defer s.Close()
+//
+//	api, err := s.API()
+//	s.SetResponse("info", clefmock.InvalidToken)
+package clefmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	clef "github.com/dutchcoders/goclef"
+)
+
+const (
+	// AppID is the application id of the API returned by Server.API
+	AppID = "mock-app-id"
+
+	// AppSecret is the application secret of the API returned by Server.API
+	AppSecret = "mock-app-secret"
+
+	// AccessToken is the access token returned by the authorize endpoint
+	AccessToken = "mock-access-token"
+
+	// ClefID is the Clef ID of the mock user
+	ClefID = 1234
+)
+
+// Response is a canned response of the mock server
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       interface{}
+}
+
+// InvalidToken is the response of the Clef API for an invalid access token
+var InvalidToken = Response{
+	StatusCode: http.StatusForbidden,
+	Body: clef.Error{
+		Message:       "Invalid token.",
+		InternalError: "Invalid token.",
+	},
+}
+
+// RateLimited returns the response of the Clef API for throttled requests.
+// Retry-After is sent in whole seconds, rounded up.
+func RateLimited(retryAfter time.Duration) Response {
+	// a Retry-After of 0 would be ignored by clients
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Retry-After": []string{strconv.Itoa(seconds)},
+		},
+		Body: clef.Error{
+			Message:       "Rate limit exceeded.",
+			InternalError: "Rate limit exceeded.",
+		},
+	}
+}
+
+// Server is a fake Clef API server
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]Response
+}
+
+// NewServer starts a mock server returning successful responses for the
+// authorize, info, logout and swag endpoints.
+func NewServer() *Server {
+	s := &Server{
+		responses: map[string]Response{
+			"authorize": {
+				Body: clef.AuthorizeResponse{AccessToken: AccessToken, Success: true},
+			},
+			"info": {
+				Body: clef.InfoResponse{
+					Info: &clef.InfoStruct{
+						ID:        ClefID,
+						FirstName: "Jane",
+						LastName:  "Doe",
+						Email:     "jane@example.com",
+					},
+					Success: true,
+				},
+			},
+			"logout": {
+				Body: clef.LogoutResponse{ID: ClefID, Success: true},
+			},
+			"swag": {
+				Body: clef.SwagResponse{Success: true},
+			},
+		},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetResponse sets the canned response for endpoint
+func (s *Server) SetResponse(endpoint string, r Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[endpoint] = r
+}
+
+// API returns a Clef API pointed at the mock server
+func (s *Server) API(opts ...clef.Option) (*clef.API, error) {
+	opts = append([]clef.Option{clef.WithBaseURL(s.URL + "/api/")}, opts...)
+	return clef.New(AppID, AppSecret, opts...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resp, ok := s.responses[path.Base(r.URL.Path)]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if resp.StatusCode != 0 {
+		w.WriteHeader(resp.StatusCode)
+	}
+
+	json.NewEncoder(w).Encode(resp.Body)
+}
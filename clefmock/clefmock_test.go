@@ -0,0 +1,72 @@
+package clefmock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	clef "github.com/dutchcoders/goclef"
+)
+
+func TestDefaultResponses(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	api, err := s.API()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ar, err := api.Authorize("code"); err != nil || ar.AccessToken != AccessToken {
+		t.Errorf("Authorize() = %+v, %v, want access token %s", ar, err, AccessToken)
+	}
+
+	if ir, err := api.Info(AccessToken); err != nil || ir.Info == nil || ir.Info.ID != ClefID {
+		t.Errorf("Info() = %+v, %v, want clef id %d", ir, err, ClefID)
+	}
+
+	if lr, err := api.Logout("logout-token"); err != nil || lr.ID != ClefID {
+		t.Errorf("Logout() = %+v, %v, want clef id %d", lr, err, ClefID)
+	}
+
+	swag := &clef.SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"}
+	if sr, err := api.Swag(swag); err != nil || !sr.Success {
+		t.Errorf("Swag() = %+v, %v, want success", sr, err)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   Response
+		check      func(error) bool
+		retryAfter time.Duration
+	}{
+		{"invalid token", InvalidToken, clef.IsInvalidTokenError, 0},
+		{"rate limited", RateLimited(30 * time.Second), clef.IsRateLimitError, 30 * time.Second},
+		{"rate limited rounds up", RateLimited(1500 * time.Millisecond), clef.IsRateLimitError, 2 * time.Second},
+		{"rate limited below a second", RateLimited(100 * time.Millisecond), clef.IsRateLimitError, time.Second},
+	}
+
+	for _, tt := range tests {
+		s := NewServer()
+		s.SetResponse("info", tt.response)
+
+		api, err := s.API()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = api.Info(AccessToken)
+		if !tt.check(err) {
+			t.Errorf("%s: Info() error = %v", tt.name, err)
+		}
+
+		var e *clef.Error
+		if !errors.As(err, &e) || e.RetryAfter != tt.retryAfter {
+			t.Errorf("%s: error = %#v, want RetryAfter %s", tt.name, err, tt.retryAfter)
+		}
+
+		s.Close()
+	}
+}
@@ -0,0 +1,46 @@
+package clef
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for cache expiry, backoff, circuit breaking
+// and token expiry. It is primarily intended for tests, to advance time
+// without real sleeps.
+type Clock interface {
+	Now() time.Time
+
+	// Sleep waits for d or until ctx is done
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// WithClock sets the clock used by the API, defaults to the real time
+func WithClock(clock Clock) Option {
+	return func(api *API) error {
+		if clock == nil {
+			clock = realClock{}
+		}
+
+		api.clock = clock
+		return nil
+	}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
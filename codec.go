@@ -0,0 +1,19 @@
+package clef
+
+import "io"
+
+// Codec decodes response bodies. The default codec uses encoding/json, a
+// faster json library can be plugged in using WithCodec.
+type Codec interface {
+	Decode(r io.Reader, v interface{}) error
+}
+
+// WithCodec sets the codec used to decode successful responses. Error
+// responses are always decoded using encoding/json, and WithStrictDecoding
+// only applies to the default codec.
+func WithCodec(codec Codec) Option {
+	return func(api *API) error {
+		api.codec = codec
+		return nil
+	}
+}
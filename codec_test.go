@@ -0,0 +1,60 @@
+package clef
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// countingCodec decodes using encoding/json and counts its calls
+type countingCodec struct {
+	calls int
+}
+
+func (c *countingCodec) Decode(r io.Reader, v interface{}) error {
+	c.calls++
+	return json.NewDecoder(r).Decode(v)
+}
+
+func TestWithCodec(t *testing.T) {
+	codec := &countingCodec{}
+	api := newTestAPI(t, jsonHandler(http.StatusOK, infoOK), WithCodec(codec))
+
+	if ir, err := api.Info("token"); err != nil || ir.Info.ID != 1 {
+		t.Fatalf("Info() = %+v, %v", ir, err)
+	}
+
+	if codec.calls != 1 {
+		t.Errorf("codec calls = %d, want 1", codec.calls)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	body := []byte(`{"success":true,"info":{"id":1,"first_name":"Jane","last_name":"Doe","email":"jane@example.com","phone_number":"+31612345678"},"scopes":["profile","email"]}`)
+
+	benchmarks := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"custom", []Option{WithCodec(&countingCodec{})}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			api, err := New("app-id", "app-secret", bm.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var ir InfoResponse
+				if err := api.decode(body, &ir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package clef
+
+import "strings"
+
+// Country is an ISO 3166-1 alpha-2 country code
+type Country string
+
+// Countries swag is most often shipped to
+const (
+	CountryAustralia     Country = "AU"
+	CountryBelgium       Country = "BE"
+	CountryCanada        Country = "CA"
+	CountryFrance        Country = "FR"
+	CountryGermany       Country = "DE"
+	CountryIreland       Country = "IE"
+	CountryJapan         Country = "JP"
+	CountryNetherlands   Country = "NL"
+	CountrySpain         Country = "ES"
+	CountrySweden        Country = "SE"
+	CountryUnitedKingdom Country = "GB"
+	CountryUnitedStates  Country = "US"
+)
+
+// countryCodes contains all officially assigned ISO 3166-1 alpha-2 codes
+var countryCodes = map[Country]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI
+		BJ BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN
+		CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK
+		FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM
+		HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+		KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK
+		ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP
+		NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW
+		SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF
+		TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+		VN VU WF WS YE YT ZA ZM ZW`) {
+		countryCodes[Country(code)] = true
+	}
+}
+
+// countryAliases maps common spellings to their ISO 3166-1 alpha-2 code
+var countryAliases = map[string]Country{
+	"USA":            CountryUnitedStates,
+	"UNITED STATES":  CountryUnitedStates,
+	"UK":             CountryUnitedKingdom,
+	"UNITED KINGDOM": CountryUnitedKingdom,
+	"GREAT BRITAIN":  CountryUnitedKingdom,
+	"ENGLAND":        CountryUnitedKingdom,
+	"HOLLAND":        CountryNetherlands,
+	"NETHERLANDS":    CountryNetherlands,
+	"DEUTSCHLAND":    CountryGermany,
+	"GERMANY":        CountryGermany,
+	"CAN":            CountryCanada,
+	"AUS":            CountryAustralia,
+}
+
+// Normalize returns the ISO 3166-1 alpha-2 code for c, accepting lower case
+// codes and common aliases such as "USA" and "UK". The second return value
+// is false if c isn't a known country.
+func (c Country) Normalize() (Country, bool) {
+	s := strings.ToUpper(strings.TrimSpace(string(c)))
+	if alias, ok := countryAliases[s]; ok {
+		return alias, true
+	}
+
+	if countryCodes[Country(s)] {
+		return Country(s), true
+	}
+
+	return c, false
+}
+
+// Valid returns true if c is an ISO 3166-1 alpha-2 code or a known alias
+func (c Country) Valid() bool {
+	_, ok := c.Normalize()
+	return ok
+}
@@ -0,0 +1,29 @@
+package clef
+
+import "testing"
+
+func TestCountryNormalize(t *testing.T) {
+	tests := []struct {
+		in   Country
+		want Country
+		ok   bool
+	}{
+		{"US", CountryUnitedStates, true},
+		{"us", CountryUnitedStates, true},
+		{" nl ", CountryNetherlands, true},
+		{"USA", CountryUnitedStates, true},
+		{"United Kingdom", CountryUnitedKingdom, true},
+		{"uk", CountryUnitedKingdom, true},
+		{"ZW", "ZW", true},
+		{"XX", "XX", false},
+		{"Atlantis", "Atlantis", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.in.Normalize()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Country(%q).Normalize() = %q, %t, want %q, %t", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package clef
+
+import "net/url"
+
+// SetCredentials replaces the application id and secret. Requests that are
+// already in flight keep using the old credentials, so secrets can be
+// rotated without downtime.
+func (api *API) SetCredentials(appID, appSecret string) {
+	api.credMu.Lock()
+	defer api.credMu.Unlock()
+
+	api.id = appID
+	api.secret = appSecret
+}
+
+// appID returns the current application id
+func (api *API) appID() string {
+	api.credMu.RLock()
+	defer api.credMu.RUnlock()
+
+	return api.id
+}
+
+// addCredentials adds the current application id and secret to form
+func (api *API) addCredentials(form url.Values) {
+	api.credMu.RLock()
+	defer api.credMu.RUnlock()
+
+	form.Add("app_id", api.id)
+	form.Add("app_secret", api.secret)
+}
@@ -0,0 +1,47 @@
+package clef
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSetCredentialsRace(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		// id and secret are rotated together
+		if id, secret := r.PostForm.Get("app_id"), r.PostForm.Get("app_secret"); "secret-"+id != secret {
+			t.Errorf("app_id %q sent with app_secret %q", id, secret)
+		}
+
+		jsonHandler(http.StatusOK, `{"success":true,"clef_id":1}`)(w, r)
+	})
+
+	api.SetCredentials("0", "secret-0")
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			id := strconv.Itoa(i)
+			api.SetCredentials(id, "secret-"+id)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := api.Logout("token"); err != nil {
+				t.Error(err)
+			}
+
+			api.LoginURL("https://app/cb")
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package clef
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+)
+
+var (
+	redactFormRe = regexp.MustCompile(`(\b(?:app_secret|access_token|refresh_token|code|logout_token)=)[^&\s"]*`)
+	redactJSONRe = regexp.MustCompile(`("(?:app_secret|access_token|refresh_token|code|logout_token)"\s*:\s*)"[^"]*"?`)
+)
+
+// WithRequestDumping enables logging of full requests and responses at
+// debug level. Credentials and tokens are redacted from the dumps.
+func WithRequestDumping(enabled bool) Option {
+	return func(api *API) error {
+		api.dumpRequests = enabled
+		return nil
+	}
+}
+
+// redact removes the application secret, OAuth codes and tokens from dump
+func redact(dump []byte) []byte {
+	dump = redactFormRe.ReplaceAll(dump, []byte("${1}REDACTED"))
+	return redactJSONRe.ReplaceAll(dump, []byte(`${1}"REDACTED"`))
+}
+
+// redactError returns the message of err with credentials and tokens
+// redacted. Network errors contain the request url, including the access
+// token of an Info call.
+func redactError(err error) string {
+	return string(redact([]byte(err.Error())))
+}
+
+func (api *API) dumpRequest(req *http.Request) {
+	if !api.dumpRequests {
+		return
+	}
+
+	if dump, err := httputil.DumpRequestOut(req, true); err == nil {
+		api.log.Debugf("Request:\n\n%s\n", string(redact(dump)))
+	}
+}
+
+func (api *API) dumpResponse(resp *http.Response, body []byte) {
+	if !api.dumpRequests {
+		return
+	}
+
+	if dump, err := httputil.DumpResponse(resp, false); err == nil {
+		api.log.Debugf("Response:\n\n%s%s\n", string(redact(dump)), string(redact(body)))
+	}
+}
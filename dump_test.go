@@ -0,0 +1,81 @@
+package clef
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"app_id=id&app_secret=secret&code=c", "app_id=id&app_secret=REDACTED&code=REDACTED"},
+		{"logout_token=lt&app_id=id", "logout_token=REDACTED&app_id=id"},
+		{"error_code=42", "error_code=42"},
+		{"GET /v1/info?access_token=abc HTTP/1.1", "GET /v1/info?access_token=REDACTED HTTP/1.1"},
+		{`Get "http://host/v1/info?access_token=abc": dial tcp`, `Get "http://host/v1/info?access_token=REDACTED": dial tcp`},
+		{`{"access_token": "abc", "success": true}`, `{"access_token": "REDACTED", "success": true}`},
+		{`{"success": true, "access_token": "ab`, `{"success": true, "access_token": "REDACTED"`},
+		{`{"access_token":"a","refresh_token":"r"}`, `{"access_token":"REDACTED","refresh_token":"REDACTED"}`},
+	}
+
+	for _, tt := range tests {
+		if got := string(redact([]byte(tt.in))); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkErrorLogRedacted(t *testing.T) {
+	var buf bytes.Buffer
+
+	api, err := New("id", "secret", WithBaseURL("http://127.0.0.1:1/api/"), WithDebugWriter(&buf), WithRetry(2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("SUPERSECRETTOKEN"); err == nil {
+		t.Fatal("expected network error")
+	}
+
+	if strings.Contains(buf.String(), "SUPERSECRETTOKEN") {
+		t.Errorf("token leaked into debug output:\n%s", buf.String())
+	}
+
+	if !strings.Contains(buf.String(), "endpoint=info") {
+		t.Errorf("expected endpoint in debug output:\n%s", buf.String())
+	}
+}
+
+func TestAuthorizeTokensRedacted(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"dumped response", `{"success":true,"access_token":"ACCESSSECRET","refresh_token":"REFRESHSECRET"}`},
+		{"decode error", `{"success":true,"access_token":"ACCESSSECRET","refresh_token":"REFRESHSECRET",`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		api := newTestAPI(t, jsonHandler(http.StatusOK, tt.body), WithDebugWriter(&buf))
+
+		_, err := api.Authorize("CODESECRET")
+		if err != nil {
+			buf.WriteString(err.Error())
+		}
+
+		if !strings.Contains(buf.String(), "refresh_token") {
+			t.Errorf("%s: expected the response in the output:\n%s", tt.name, buf.String())
+		}
+
+		for _, secret := range []string{"ACCESSSECRET", "REFRESHSECRET", "CODESECRET"} {
+			if strings.Contains(buf.String(), secret) {
+				t.Errorf("%s: %s leaked:\n%s", tt.name, secret, buf.String())
+			}
+		}
+	}
+}
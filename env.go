@@ -0,0 +1,86 @@
+package clef
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrMissingConfig will be returned when required configuration is missing
+var ErrMissingConfig = errors.New("clef: missing configuration")
+
+// NewFromEnv returns a new Clef API configured from the environment. The
+// application id and secret are read from CLEF_APP_ID and CLEF_APP_SECRET,
+// CLEF_BASE_URL optionally overrides the base url (eg. to point to a mock
+// server during development).
+func NewFromEnv(opts ...Option) (*API, error) {
+	appID := os.Getenv("CLEF_APP_ID")
+	if appID == "" {
+		return nil, fmt.Errorf("%w: CLEF_APP_ID not set", ErrMissingConfig)
+	}
+
+	appSecret := os.Getenv("CLEF_APP_SECRET")
+	if appSecret == "" {
+		return nil, fmt.Errorf("%w: CLEF_APP_SECRET not set", ErrMissingConfig)
+	}
+
+	if baseURL := os.Getenv("CLEF_BASE_URL"); baseURL != "" {
+		opts = append([]Option{WithBaseURL(baseURL)}, opts...)
+	}
+
+	return New(appID, appSecret, opts...)
+}
+
+// InitializeFromEnv initializes the Clef API from the environment, see
+// NewFromEnv.
+func InitializeFromEnv(opts ...Option) error {
+	if c, err := NewFromEnv(opts...); err != nil {
+		return err
+	} else {
+		defaultAPI.Store(c)
+		return nil
+	}
+}
+
+// NewFromFiles returns a new Clef API with the application id and secret
+// read from files, eg. mounted Kubernetes secrets. Surrounding whitespace,
+// like a trailing newline, is trimmed.
+func NewFromFiles(appIDPath, appSecretPath string, opts ...Option) (*API, error) {
+	appID, err := readSecretFile(appIDPath)
+	if err != nil {
+		return nil, err
+	}
+
+	appSecret, err := readSecretFile(appSecretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(appID, appSecret, opts...)
+}
+
+// InitializeFromFiles initializes the Clef API with the application id and
+// secret read from files, see NewFromFiles.
+func InitializeFromFiles(appIDPath, appSecretPath string, opts ...Option) error {
+	if c, err := NewFromFiles(appIDPath, appSecretPath, opts...); err != nil {
+		return err
+	} else {
+		defaultAPI.Store(c)
+		return nil
+	}
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("clef: reading %s: %w", path, err)
+	}
+
+	value := strings.TrimSpace(string(b))
+	if value == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrMissingConfig, path)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,118 @@
+package clef
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		secret string
+		err    string
+	}{
+		{"configured", "app-id", "app-secret", ""},
+		{"missing id", "", "app-secret", "CLEF_APP_ID"},
+		{"missing secret", "app-id", "", "CLEF_APP_SECRET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CLEF_APP_ID", tt.id)
+			t.Setenv("CLEF_APP_SECRET", tt.secret)
+			t.Setenv("CLEF_BASE_URL", "")
+
+			api, err := NewFromEnv()
+			if tt.err == "" {
+				if err != nil || api.id != tt.id || api.secret != tt.secret {
+					t.Errorf("NewFromEnv() = %+v, %v", api, err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrMissingConfig) || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("NewFromEnv() error = %v, want ErrMissingConfig naming %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestInitializeFromEnvBaseURL(t *testing.T) {
+	restoreDefault(t)
+
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}))
+	defer s.Close()
+
+	t.Setenv("CLEF_APP_ID", "app-id")
+	t.Setenv("CLEF_APP_SECRET", "app-secret")
+	t.Setenv("CLEF_BASE_URL", s.URL+"/api/")
+
+	if err := InitializeFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Info("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Error("the default API doesn't use CLEF_BASE_URL")
+	}
+}
+
+func TestNewFromFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	id := write("id", "app-id\n")
+	secret := write("secret", "  app-secret\r\n")
+	empty := write("empty", "\n")
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name        string
+		id, secret  string
+		missingConf bool
+		notExist    bool
+	}{
+		{"trailing newlines", id, secret, false, false},
+		{"missing id file", missing, secret, false, true},
+		{"missing secret file", id, missing, false, true},
+		{"empty secret file", id, empty, true, false},
+	}
+
+	for _, tt := range tests {
+		api, err := NewFromFiles(tt.id, tt.secret)
+		if !tt.missingConf && !tt.notExist {
+			if err != nil || api.id != "app-id" || api.secret != "app-secret" {
+				t.Errorf("%s: NewFromFiles() = %+v, %v", tt.name, api, err)
+			}
+
+			continue
+		}
+
+		if errors.Is(err, ErrMissingConfig) != tt.missingConf || errors.Is(err, os.ErrNotExist) != tt.notExist {
+			t.Errorf("%s: NewFromFiles() error = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package clef
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken matches errors returned for invalid access tokens
+	ErrInvalidToken = errors.New("clef: invalid token")
+
+	// ErrRateLimited matches errors returned when requests are throttled
+	ErrRateLimited = errors.New("clef: rate limited")
+
+	// ErrExpiredToken matches errors returned for expired access tokens
+	ErrExpiredToken = errors.New("clef: expired token")
+
+	// ErrRevokedToken matches errors returned for revoked access tokens
+	ErrRevokedToken = errors.New("clef: revoked token")
+
+	// ErrServiceUnavailable matches errors returned while the Clef API is
+	// unavailable, eg. during maintenance
+	ErrServiceUnavailable = errors.New("clef: service unavailable")
+
+	// ErrBadCredentials matches errors returned for an invalid application
+	// id or application secret
+	ErrBadCredentials = errors.New("clef: bad credentials")
+)
+
+// messageInvalidToken is the message the Clef API returns for an invalid
+// access token
+const messageInvalidToken = "Invalid token."
+
+// Error contains Clef Error messages. Only the "Invalid token." message is
+// known to be returned verbatim by the Clef API (IsInvalidTokenError). The
+// other predicates classify client errors (status below 500) by the words
+// in their message, error and context, case insensitive:
+//
+//	IsExpiredTokenError  "token" and "expired"
+//	IsRevokedTokenError  "token" and "revoked"
+//	IsInvalidAppError    "app id" or "app_id"
+//	IsAppDisabledError   "app" and "disabled"
+//	ErrBadCredentials    "app id", "app_id" or "app secret", "app_secret"
+type Error struct {
+	Message       string `json:"message"`
+	Context       string `json:"context"`
+	InternalError string `json:"error"`
+
+	// StatusCode is the http status code of the response
+	StatusCode int `json:"-"`
+
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	// Clef sends it with 429 and 503 responses.
+	RetryAfter time.Duration `json:"-"`
+
+	// body contains the start of a response body that couldn't be decoded
+	body string
+}
+
+// Error implements error interface
+func (e Error) Error() string {
+	if e.InternalError != "" {
+		return e.InternalError
+	} else if e.Message != "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("clef: unexpected status %d: %s", e.StatusCode, e.body)
+}
+
+// Is maps the error onto the sentinel errors of this package, so
+// errors.Is(err, ErrInvalidToken) can be used.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidToken:
+		return e.Message == messageInvalidToken
+	case ErrExpiredToken:
+		return e.mentions("token", "expired")
+	case ErrRevokedToken:
+		return e.mentions("token", "revoked")
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServiceUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	case ErrBadCredentials:
+		return e.mentions("app id") || e.mentions("app_id") || e.mentions("app secret") || e.mentions("app_secret")
+	}
+
+	return false
+}
+
+// mentions returns true if e is a client error and all words occur in its
+// message, error or context
+func (e *Error) mentions(words ...string) bool {
+	if e.StatusCode >= 500 {
+		return false
+	}
+
+	text := strings.ToLower(e.Message + " " + e.InternalError + " " + e.Context)
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContextField returns the context of the error as reported by the Clef API
+func (e *Error) ContextField() string {
+	return e.Context
+}
+
+// IsInvalidTokenError returns true if err is a invalid token error.
+func IsInvalidTokenError(err error) bool {
+	return errors.Is(err, ErrInvalidToken)
+}
+
+// IsExpiredTokenError returns true if err is an expired token error. The
+// user should be asked to log in again.
+func IsExpiredTokenError(err error) bool {
+	return errors.Is(err, ErrExpiredToken)
+}
+
+// IsRevokedTokenError returns true if err is a revoked token error, the user
+// revoked access for the application.
+func IsRevokedTokenError(err error) bool {
+	return errors.Is(err, ErrRevokedToken)
+}
+
+// IsInvalidAppError returns true if err is caused by an unknown application
+// id.
+func IsInvalidAppError(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.mentions("app id") || e.mentions("app_id")
+	}
+
+	return false
+}
+
+// IsAppDisabledError returns true if err is caused by a disabled
+// application.
+func IsAppDisabledError(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.mentions("app", "disabled")
+	}
+
+	return false
+}
+
+// IsRateLimitError returns true if err is a Clef error caused by a 429
+// response. The RetryAfter field of the error contains the requested delay.
+func IsRateLimitError(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsServiceUnavailableError returns true if err is a Clef error caused by a
+// 503 response, eg. during maintenance. The RetryAfter field of the error
+// contains the requested delay when the response carried one.
+func IsServiceUnavailableError(err error) bool {
+	return errors.Is(err, ErrServiceUnavailable)
+}
+
+// IsServerError returns true if err is a Clef error caused by a 5xx response.
+func IsServerError(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode >= 500 && e.StatusCode < 600
+	}
+
+	return false
+}
+
+// parseRetryAfter parses the Retry-After header, either in seconds or as an
+// HTTP date relative to now
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
+
+// maxErrorBody is the number of bytes of an undecodable body kept in Error
+const maxErrorBody = 512
+
+// newStatusError returns the Error for a non successful response
+func newStatusError(resp *http.Response, body []byte, now time.Time) *Error {
+	e := Error{}
+	if err := json.Unmarshal(body, &e); err != nil || (e.InternalError == "" && e.Message == "") {
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+
+		e = Error{body: strings.TrimSpace(string(body))}
+	}
+
+	e.StatusCode = resp.StatusCode
+	e.RetryAfter = parseRetryAfter(resp.Header, now)
+	return &e
+}
+
+// endpointError wraps an error with the endpoint of the failing request
+type endpointError struct {
+	endpoint string
+	err      error
+}
+
+// Error implements error interface
+func (e *endpointError) Error() string {
+	// the wrapped errors of this package already carry the prefix
+	return fmt.Sprintf("clef: %s request failed: %s", e.endpoint, strings.TrimPrefix(e.err.Error(), "clef: "))
+}
+
+// Unwrap returns the wrapped error
+func (e *endpointError) Unwrap() error {
+	return e.err
+}
+
+// decodeError is returned when the body of a successful response can't be
+// decoded. It holds the start of the body, with tokens redacted.
+type decodeError struct {
+	endpoint string
+	body     string
+	err      error
+}
+
+// newDecodeError wraps err, returned while decoding the body of a successful
+// response, with the endpoint and the start of the body
+func newDecodeError(endpoint string, body []byte, err error) error {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+
+	return &decodeError{endpoint: endpoint, body: string(redact(body)), err: err}
+}
+
+// Error implements error interface
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("clef: failed to decode %s response: %s (body %q)", e.endpoint, e.err, e.body)
+}
+
+// Unwrap returns the error of the decoder
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,80 @@
+package clef
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorPredicates(t *testing.T) {
+	type predicates struct {
+		invalid, expired, revoked, invalidApp, disabled, badCredentials bool
+	}
+
+	tests := []struct {
+		name string
+		err  *Error
+		want predicates
+	}{
+		{"invalid token", &Error{Message: "Invalid token.", StatusCode: http.StatusForbidden}, predicates{invalid: true}},
+		{"expired token", &Error{Message: "Token expired.", StatusCode: http.StatusUnauthorized}, predicates{expired: true}},
+		{"expired in context", &Error{Message: "Unauthorized.", Context: "access token has expired", StatusCode: http.StatusOK}, predicates{expired: true}},
+		{"revoked token", &Error{InternalError: "Token has been revoked", StatusCode: http.StatusForbidden}, predicates{revoked: true}},
+		{"invalid app id", &Error{Message: "Invalid App ID.", StatusCode: http.StatusBadRequest}, predicates{invalidApp: true, badCredentials: true}},
+		{"invalid app secret", &Error{Message: "Invalid App Secret.", StatusCode: http.StatusBadRequest}, predicates{badCredentials: true}},
+		{"app disabled", &Error{Message: "App disabled.", StatusCode: http.StatusForbidden}, predicates{disabled: true}},
+		{"server error", &Error{Message: "Token store expired the cache", StatusCode: http.StatusInternalServerError}, predicates{}},
+		{"other", &Error{Message: "Something went wrong.", StatusCode: http.StatusBadRequest}, predicates{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// predicates see through wrapping
+			var e error = tt.err
+			err := fmt.Errorf("clef: info request failed: %w", e)
+
+			got := predicates{
+				invalid:        IsInvalidTokenError(err),
+				expired:        IsExpiredTokenError(err),
+				revoked:        IsRevokedTokenError(err),
+				invalidApp:     IsInvalidAppError(err),
+				disabled:       IsAppDisabledError(err),
+				badCredentials: errors.Is(err, ErrBadCredentials),
+			}
+
+			if got != tt.want {
+				t.Errorf("predicates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusPredicates(t *testing.T) {
+	tests := []struct {
+		status                                int
+		rateLimited, unavailable, serverError bool
+	}{
+		{http.StatusBadRequest, false, false, false},
+		{http.StatusTooManyRequests, true, false, false},
+		{http.StatusInternalServerError, false, false, true},
+		{http.StatusServiceUnavailable, false, true, true},
+	}
+
+	for _, tt := range tests {
+		var e error = &Error{StatusCode: tt.status}
+		err := fmt.Errorf("wrapped: %w", e)
+
+		if got := IsRateLimitError(err); got != tt.rateLimited {
+			t.Errorf("%d: IsRateLimitError() = %t", tt.status, got)
+		}
+
+		if got := IsServiceUnavailableError(err); got != tt.unavailable {
+			t.Errorf("%d: IsServiceUnavailableError() = %t", tt.status, got)
+		}
+
+		if got := IsServerError(err); got != tt.serverError {
+			t.Errorf("%d: IsServerError() = %t", tt.status, got)
+		}
+	}
+}
@@ -12,26 +12,55 @@ const (
 	CLEF_APP_SECRET = "2125d80f4583c52c46f8084bcc030c9b"
 )
 
+// stateCookie holds the OAuth state of the login button, it is verified in
+// the OAuth callback to protect against CSRF
+const stateCookie = "oauth_state"
+
+var (
+	api      *clef.API
+	sessions *clef.SessionManager
+)
+
 func init() {
-	clef.MustInitialize(CLEF_APP_ID, CLEF_APP_SECRET)
+	var err error
+	if api, err = clef.New(CLEF_APP_ID, CLEF_APP_SECRET); err != nil {
+		panic(err)
+	}
+
+	// the session cookie is HttpOnly, Secure and SameSite=Lax
+	sessions = clef.NewSessionManager(api)
 }
 
 func logoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{Name: "access_token", Value: ""})
+	sessions.Logout(w, r)
 
 	logoutToken := r.FormValue("logout_token")
-	clef.Logout(logoutToken)
+	api.Logout(logoutToken)
 
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
 func oauthCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	// the user denied access
+	if err := clef.ParseCallbackError(r); err != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || !clef.VerifyState(cookie.Value, r.FormValue("state")) {
+		http.Error(w, "invalid state", http.StatusForbidden)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/", MaxAge: -1})
+
 	code := r.FormValue("code")
 
-	if ar, err := clef.Authorize(code); err != nil {
+	if ar, err := api.Authorize(code); err != nil {
 		panic(err)
 	} else {
-		http.SetCookie(w, &http.Cookie{Name: "access_token", Value: ar.AccessToken})
+		sessions.Login(w, ar)
 		http.Redirect(w, r, "/", http.StatusFound)
 	}
 }
@@ -40,19 +69,38 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	bag := struct {
 		Info     *clef.InfoStruct
 		Error    string
+		State    string
 		LoggedIn bool
 	}{
 		LoggedIn: false,
 	}
 
-	if cookie, err := r.Cookie("access_token"); err != nil {
-	} else if ir, err := clef.Info(cookie.Value); err != nil {
+	if info, err := sessions.Current(r); err == clef.ErrNoSession {
+	} else if err != nil {
 		if clef.IsInvalidTokenError(err) {
 		} else {
 			bag.Error = err.Error()
 		}
 	} else {
-		bag.Info = ir.Info
+		bag.Info = info
+	}
+
+	if bag.Info == nil {
+		state, err := clef.GenerateState()
+		if err != nil {
+			panic(err)
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Value:    state,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		bag.State = state
 	}
 
 	if t, err := template.ParseFiles("templates/index.html"); err != nil {
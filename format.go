@@ -0,0 +1,37 @@
+package clef
+
+import "fmt"
+
+// redactToken hides all but the last 4 characters of token
+func redactToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+
+	return "****" + token[len(token)-4:]
+}
+
+// String returns a summary of the response with the access token redacted
+func (ar AuthorizeResponse) String() string {
+	return fmt.Sprintf("AuthorizeResponse{AccessToken: %s, Success: %t}", redactToken(ar.AccessToken), ar.Success)
+}
+
+// String returns a summary of the response
+func (lr LogoutResponse) String() string {
+	return fmt.Sprintf("LogoutResponse{ID: %d, Success: %t, Source: %s}", lr.ID, lr.Success, lr.Source)
+}
+
+// String returns a summary of the user info
+func (i InfoStruct) String() string {
+	return fmt.Sprintf("InfoStruct{ID: %d, Name: %q, Email: %q}", i.ID, i.FullName(), i.Email)
+}
+
+// String returns a summary of the response
+func (ir InfoResponse) String() string {
+	return fmt.Sprintf("InfoResponse{Info: %v, Scopes: %v, Success: %t}", ir.Info, ir.Scopes, ir.Success)
+}
+
+// String returns a summary of the response
+func (sr SwagResponse) String() string {
+	return fmt.Sprintf("SwagResponse{Success: %t}", sr.Success)
+}
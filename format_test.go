@@ -0,0 +1,40 @@
+package clef
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStringRedactsTokens(t *testing.T) {
+	ar := AuthorizeResponse{AccessToken: "ACCESSSECRET1234", RefreshToken: "REFRESHSECRET", Success: true}
+
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"value", ar},
+		{"pointer", &ar},
+		{"slice", []AuthorizeResponse{ar}},
+	}
+
+	for _, tt := range tests {
+		for _, format := range []string{"%v", "%+v", "%s"} {
+			got := fmt.Sprintf(format, tt.v)
+			if strings.Contains(got, "ACCESSSECRET") || strings.Contains(got, "REFRESHSECRET") {
+				t.Errorf("%s: Sprintf(%q) = %s, want tokens redacted", tt.name, format, got)
+			} else if !strings.Contains(got, "****1234") {
+				t.Errorf("%s: Sprintf(%q) = %s, want the last 4 characters of the access token", tt.name, format, got)
+			}
+		}
+	}
+}
+
+func TestInfoResponseString(t *testing.T) {
+	ir := InfoResponse{Info: &InfoStruct{ID: 1, FirstName: "Jane", LastName: "Doe"}, Scopes: []Scope{ScopeEmail}, Success: true}
+
+	want := `InfoResponse{Info: InfoStruct{ID: 1, Name: "Jane Doe", Email: ""}, Scopes: [email], Success: true}`
+	if got := fmt.Sprint(ir); got != want {
+		t.Errorf("Sprint() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// healthCheckToken is an intentionally invalid logout token, used to make an
+// authenticated request without side effects.
+const healthCheckToken = "goclef-health-check"
+
+// HealthCheck verifies that the Clef API is reachable and accepts the
+// application credentials. It returns an error matching ErrBadCredentials
+// when the credentials are rejected, and the underlying error when the API
+// can't be reached or the response is unexpected.
+func (api *API) HealthCheck(ctx context.Context) error {
+	if err := api.ready(); err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Add("logout_token", healthCheckToken)
+	api.addCredentials(form)
+
+	request, err := api.NewRequestContext(ctx, "POST", "logout", form)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = do[LogoutResponse](api, request)
+	if err == nil || errors.Is(err, ErrBadCredentials) {
+		return err
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		return err
+	} else if rejectsProbeToken(e) {
+		// the credentials were accepted, only the probe token was rejected
+		return nil
+	} else if e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: %w", ErrBadCredentials, err)
+	}
+
+	return err
+}
+
+// rejectsProbeToken returns true if e is the rejection of the logout token
+// of the health check, rather than of the request or the credentials
+func rejectsProbeToken(e *Error) bool {
+	if e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests {
+		return false
+	}
+
+	return errors.Is(e, ErrInvalidToken) || strings.Contains(strings.ToLower(e.Message+" "+e.InternalError), "token")
+}
@@ -0,0 +1,54 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		healthy        bool
+		badCredentials bool
+	}{
+		{"token rejected", http.StatusOK, `{"success":false,"error":"Invalid logout token."}`, true, false},
+		{"invalid token", http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`, true, false},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"Unauthorized"}`, false, true},
+		{"forbidden", http.StatusForbidden, `{"error":"Forbidden"}`, false, true},
+		{"invalid secret", http.StatusBadRequest, `{"error":"Invalid App Secret.","message":"Invalid App Secret."}`, false, true},
+		{"bad request", http.StatusBadRequest, `{"error":"Bad request"}`, false, false},
+		{"server error", http.StatusInternalServerError, `{"error":"Internal error, token store unavailable"}`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, jsonHandler(tt.status, tt.body))
+
+			err := api.HealthCheck(context.Background())
+			if tt.healthy {
+				if err != nil {
+					t.Fatalf("HealthCheck() = %v, want nil", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("HealthCheck() = nil, want error")
+			}
+
+			if got := errors.Is(err, ErrBadCredentials); got != tt.badCredentials {
+				t.Errorf("errors.Is(%v, ErrBadCredentials) = %t, want %t", err, got, tt.badCredentials)
+			}
+
+			if !strings.Contains(err.Error(), "logout request failed") {
+				t.Errorf("error %q doesn't mention the endpoint", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package clef
+
+import "net/http"
+
+// RequestHook is called with every request before it is sent
+type RequestHook func(*http.Request) error
+
+// ResponseHook is called with every response before it is decoded
+type ResponseHook func(*http.Response) error
+
+// WithRequestHook adds a hook that is called before every request, eg. to
+// add headers. Hooks are called in the order they have been added, when a
+// hook returns an error the request isn't sent and the error is returned.
+// Hooks run for every attempt when retrying.
+func WithRequestHook(hook RequestHook) Option {
+	return func(api *API) error {
+		api.requestHooks = append(api.requestHooks, hook)
+		return nil
+	}
+}
+
+// WithResponseHook adds a hook that is called for every response before it
+// is decoded. Hooks are called in the order they have been added, when a
+// hook returns an error it is returned instead of the decoded response.
+// Hooks must not consume the response body.
+func WithResponseHook(hook ResponseHook) Option {
+	return func(api *API) error {
+		api.responseHooks = append(api.responseHooks, hook)
+		return nil
+	}
+}
+
+// WithRequestInspector registers inspect to be called with the fully built
+// request right before it is sent, eg. to assert on headers in tests. It is
+// a lightweight alternative to WithRequestHook; inspect must not modify the
+// request.
+func WithRequestInspector(inspect func(*http.Request)) Option {
+	return func(api *API) error {
+		api.inspector = inspect
+		return nil
+	}
+}
+
+func (api *API) runRequestHooks(req *http.Request) error {
+	for _, hook := range api.requestHooks {
+		if err := hook(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (api *API) runResponseHooks(resp *http.Response) error {
+	for _, hook := range api.responseHooks {
+		if err := hook(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package clef
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRequestHooks(t *testing.T) {
+	errHook := errors.New("hook failed")
+
+	var calls []string
+	hook := func(name string, err error) RequestHook {
+		return func(req *http.Request) error {
+			calls = append(calls, name)
+			req.Header.Add("X-Hook", name)
+			return err
+		}
+	}
+
+	tests := []struct {
+		name     string
+		hooks    []RequestHook
+		calls    []string
+		requests int32
+		err      error
+	}{
+		{"in order", []RequestHook{hook("first", nil), hook("second", nil)}, []string{"first", "second"}, 1, nil},
+		{"error stops the request", []RequestHook{hook("first", errHook), hook("second", nil)}, []string{"first"}, 0, errHook},
+	}
+
+	for _, tt := range tests {
+		calls = nil
+
+		var requests int32
+		var headers []string
+
+		opts := []Option{}
+		for _, h := range tt.hooks {
+			opts = append(opts, WithRequestHook(h))
+		}
+
+		api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			headers = r.Header.Values("X-Hook")
+			jsonHandler(http.StatusOK, infoOK)(w, r)
+		}, opts...)
+
+		_, err := api.Info("token")
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+
+		if requests != tt.requests {
+			t.Errorf("%s: requests = %d, want %d", tt.name, requests, tt.requests)
+		}
+
+		if len(calls) != len(tt.calls) || (tt.requests > 0 && len(headers) != len(tt.calls)) {
+			t.Errorf("%s: calls = %v, headers = %v, want %v", tt.name, calls, headers, tt.calls)
+		}
+
+		for i := range calls {
+			if calls[i] != tt.calls[i] {
+				t.Errorf("%s: calls = %v, want %v", tt.name, calls, tt.calls)
+			}
+		}
+	}
+}
+
+func TestResponseHooks(t *testing.T) {
+	errHook := errors.New("hook failed")
+
+	var calls []string
+	hook := func(name string, err error) ResponseHook {
+		return func(resp *http.Response) error {
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("hook %s called with status %d", name, resp.StatusCode)
+			}
+
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	tests := []struct {
+		name  string
+		hooks []ResponseHook
+		calls []string
+		err   error
+	}{
+		{"in order", []ResponseHook{hook("first", nil), hook("second", nil)}, []string{"first", "second"}, nil},
+		{"error is returned", []ResponseHook{hook("first", errHook), hook("second", nil)}, []string{"first"}, errHook},
+	}
+
+	for _, tt := range tests {
+		calls = nil
+
+		opts := []Option{}
+		for _, h := range tt.hooks {
+			opts = append(opts, WithResponseHook(h))
+		}
+
+		api := newTestAPI(t, jsonHandler(http.StatusOK, infoOK), opts...)
+
+		ir, err := api.Info("token")
+		if !errors.Is(err, tt.err) || (err != nil && ir != nil) {
+			t.Errorf("%s: Info() = %v, %v, want error %v", tt.name, ir, err, tt.err)
+		}
+
+		if len(calls) != len(tt.calls) || calls[0] != tt.calls[0] {
+			t.Errorf("%s: calls = %v, want %v", tt.name, calls, tt.calls)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidPhoneNumber will be returned when a phone number can't be
+// normalized
+var ErrInvalidPhoneNumber = errors.New("clef: invalid phone number")
+
+// callingCodes maps ISO 3166 region codes to their country calling code
+var callingCodes = map[string]string{
+	"AT": "43", "AU": "61", "BE": "32", "BR": "55", "CA": "1",
+	"CH": "41", "CN": "86", "DE": "49", "DK": "45", "ES": "34",
+	"FI": "358", "FR": "33", "GB": "44", "IE": "353", "IN": "91",
+	"IT": "39", "JP": "81", "LU": "352", "MX": "52", "NL": "31",
+	"NO": "47", "NZ": "64", "PL": "48", "PT": "351", "SE": "46",
+	"SG": "65", "US": "1", "ZA": "27",
+}
+
+// FullName returns the first and last name of the user, joined by a space
+// when both are set.
+func (i *InfoStruct) FullName() string {
+	return strings.TrimSpace(strings.TrimSpace(i.FirstName) + " " + strings.TrimSpace(i.LastName))
+}
+
+// ValidEmail returns true if the email address of the user is a valid,
+// bare email address.
+func (i *InfoStruct) ValidEmail() bool {
+	addr, err := mail.ParseAddress(i.Email)
+	return err == nil && addr.Address == i.Email
+}
+
+// NormalizedPhone returns the phone number in E.164 format. Numbers without
+// an international prefix are interpreted as national numbers of
+// defaultRegion (an ISO 3166 alpha-2 code, eg. "US").
+func (i *InfoStruct) NormalizedPhone(defaultRegion string) (string, error) {
+	number := strings.TrimSpace(i.PhoneNumber)
+	if strings.HasPrefix(number, "+") || strings.HasPrefix(number, "00") {
+		// drop the trunk prefix of international numbers written like
+		// +31 (0)20 1234567
+		number = strings.Replace(number, "(0)", "", 1)
+	}
+
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.', '/':
+			return -1
+		}
+		return r
+	}, number)
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	default:
+		code, ok := callingCodes[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return "", fmt.Errorf("clef: unknown region %q", defaultRegion)
+		}
+
+		if code == "1" {
+			// north american numbers may be written with a leading 1
+			if len(number) == 11 && strings.HasPrefix(number, "1") {
+				number = number[1:]
+			}
+		} else if code != "39" {
+			// strip the national trunk prefix, italian numbers keep it
+			number = strings.TrimPrefix(number, "0")
+		}
+
+		number = code + number
+	}
+
+	if len(number) < 8 || len(number) > 15 || strings.HasPrefix(number, "0") {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	return "+" + number, nil
+}
+
+// TokenMismatchError will be returned when an access token belongs to
+// another Clef user than expected
+type TokenMismatchError struct {
+	Expected ClefID
+	Actual   ClefID
+}
+
+// Error implements error interface
+func (e *TokenMismatchError) Error() string {
+	return fmt.Sprintf("clef: token belongs to clef id %s, expected %s", e.Actual, e.Expected)
+}
+
+// VerifyTokenForID verifies that token belongs to the Clef user expected,
+// eg. to defend against session fixation after Authorize. A token of
+// another user returns false and a *TokenMismatchError.
+func (api *API) VerifyTokenForID(ctx context.Context, token string, expected ClefID) (bool, error) {
+	ir, err := api.InfoContext(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	if ir.Info == nil {
+		return false, errors.New("clef: info response without user info")
+	}
+
+	if ir.Info.ID != expected {
+		return false, &TokenMismatchError{Expected: expected, Actual: ir.Info.ID}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizedPhone(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		region string
+		want   string
+		err    bool
+	}{
+		{"national", "020 123 4567", "NL", "+31201234567", false},
+		{"international", "+31 20 123 4567", "US", "+31201234567", false},
+		{"international 00", "0031 20 123 4567", "US", "+31201234567", false},
+		{"international trunk prefix", "+31 (0)20 1234567", "US", "+31201234567", false},
+		{"international 00 trunk prefix", "0044 (0)20 7946 0958", "NL", "+442079460958", false},
+		{"nanp", "(415) 555-2671", "US", "+14155552671", false},
+		{"nanp leading 1", "1-415-555-2671", "CA", "+14155552671", false},
+		{"italian keeps trunk prefix", "06 1234 5678", "IT", "+390612345678", false},
+		{"too short", "1234", "NL", "", true},
+		{"letters", "020 CALL NOW", "NL", "", true},
+		{"unknown region", "020 123 4567", "XX", "", true},
+	}
+
+	for _, tt := range tests {
+		i := InfoStruct{PhoneNumber: tt.number}
+
+		got, err := i.NormalizedPhone(tt.region)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%s: NormalizedPhone(%q, %q) = %q, %v, want %q", tt.name, tt.number, tt.region, got, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyTokenForID(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		ok       bool
+		mismatch bool
+	}{
+		{"match", `{"success":true,"info":{"id":42}}`, true, false},
+		{"mismatch", `{"success":true,"info":{"id":7}}`, false, true},
+		{"without info", `{"success":true}`, false, false},
+	}
+
+	for _, tt := range tests {
+		api := newTestAPI(t, jsonHandler(http.StatusOK, tt.body))
+
+		ok, err := api.VerifyTokenForID(context.Background(), "token", 42)
+		if ok != tt.ok || (err == nil) != tt.ok {
+			t.Errorf("%s: VerifyTokenForID() = %t, %v, want %t", tt.name, ok, err, tt.ok)
+		}
+
+		var me *TokenMismatchError
+		if got := errors.As(err, &me); got != tt.mismatch {
+			t.Errorf("%s: error = %v, want *TokenMismatchError %t", tt.name, err, tt.mismatch)
+		} else if got && (me.Expected != 42 || me.Actual != 7) {
+			t.Errorf("%s: error = %+v, want expected 42 and actual 7", tt.name, me)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package clef
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithMaxConcurrentRequests limits the number of requests in flight to the
+// Clef API to n, regardless of the number of goroutines using the API.
+// Requests wait for a free slot until their context is done.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(api *API) error {
+		if n < 1 {
+			return fmt.Errorf("clef: invalid max concurrent requests %d", n)
+		}
+
+		api.sem = make(chan struct{}, n)
+		return nil
+	}
+}
+
+// acquire waits for a request slot, the returned func releases it
+func (api *API) acquire(ctx context.Context) (func(), error) {
+	if api.sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case api.sem <- struct{}{}:
+		return func() { <-api.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
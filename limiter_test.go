@@ -0,0 +1,79 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, max int32
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}, WithMaxConcurrentRequests(5))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := api.Info("token"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&max); got > 5 || got < 1 {
+		t.Errorf("max concurrency = %d, want at most 5", got)
+	}
+}
+
+func TestMaxConcurrentRequestsHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}, WithMaxConcurrentRequests(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		api.Info("first")
+	}()
+
+	// wait for the first request to take the slot
+	for len(api.sem) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := api.InfoContext(ctx, "second"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,104 @@
+package clef
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is the interface used for debug output. It is satisfied by most
+// logging libraries, eg. *logging.Logger of github.com/op/go-logging.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// warner is implemented by loggers that support warnings. Loggers that don't
+// receive warnings as debug output.
+type warner interface {
+	Warningf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+
+// writerLogger writes every message as a line to w
+type writerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *writerLogger) Debugf(format string, args ...interface{}) {
+	l.printf("DEBUG", format, args...)
+}
+
+func (l *writerLogger) Warningf(format string, args ...interface{}) {
+	l.printf("WARNING", format, args...)
+}
+
+func (l *writerLogger) printf(level, format string, args ...interface{}) {
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fmt.Fprintf(l.w, "%s %s %s\n", time.Now().Format(time.RFC3339), level, msg)
+}
+
+// WithDebugWriter writes debug output, including redacted dumps of all
+// requests and responses, to w. It is the simplest way to debug without
+// setting up a Logger.
+func WithDebugWriter(w io.Writer) Option {
+	return func(api *API) error {
+		if w == nil {
+			return errors.New("clef: nil debug writer")
+		}
+
+		api.log = &writerLogger{w: w}
+		api.dumpRequests = true
+		return nil
+	}
+}
+
+// warnf logs a warning, falling back to debug output
+func (api *API) warnf(format string, args ...interface{}) {
+	if w, ok := api.log.(warner); ok {
+		w.Warningf(format, args...)
+		return
+	}
+
+	api.log.Debugf(format, args...)
+}
+
+// WithSlowRequestThreshold logs a warning for every request that takes
+// longer than d
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(api *API) error {
+		api.slowThreshold = d
+		return nil
+	}
+}
+
+// logRequest logs a completed request as key=value fields, which are easily
+// parsed by log aggregators.
+func (api *API) logRequest(req *http.Request, meta ResponseMeta, err error) {
+	requestID := ""
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		requestID = fmt.Sprintf(" request_id=%q", id)
+	}
+
+	if err != nil {
+		api.log.Debugf("clef request method=%s endpoint=%s duration=%s error=%q%s", req.Method, api.endpoint(req), meta.Duration, redactError(err), requestID)
+		return
+	}
+
+	api.log.Debugf("clef request method=%s endpoint=%s status=%d duration=%s%s", req.Method, api.endpoint(req), meta.StatusCode, meta.Duration, requestID)
+
+	if api.slowThreshold > 0 && meta.Duration > api.slowThreshold {
+		api.warnf("clef slow request method=%s endpoint=%s duration=%s threshold=%s%s", req.Method, api.endpoint(req), meta.Duration, api.slowThreshold, requestID)
+	}
+}
@@ -0,0 +1,98 @@
+package clef
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger records debug and warning messages
+type recordingLogger struct {
+	mu       sync.Mutex
+	debug    []string
+	warnings []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warningf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}
+
+	log := &recordingLogger{}
+	api := newTestAPI(t, slow, WithLogger(log), WithSlowRequestThreshold(20*time.Millisecond))
+
+	if _, err := api.Info("fast"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(log.warnings) != 0 {
+		t.Errorf("warnings = %q, want none for a fast request", log.warnings)
+	}
+
+	if _, err := api.Info("slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], "clef slow request method=GET endpoint=info") {
+		t.Errorf("warnings = %q, want a slow request warning", log.warnings)
+	}
+
+	// loggers without warnings receive debug output
+	debugOnly := &struct{ Logger }{&recordingLogger{}}
+	api = newTestAPI(t, slow, WithLogger(debugOnly), WithSlowRequestThreshold(20*time.Millisecond))
+
+	if _, err := api.Info("slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	if debug := debugOnly.Logger.(*recordingLogger).debug; len(debug) != 2 || !strings.Contains(debug[1], "clef slow request") {
+		t.Errorf("debug = %q, want the slow request warning", debug)
+	}
+}
+
+func TestWithDebugWriter(t *testing.T) {
+	var buf bytes.Buffer
+	api := newTestAPI(t, jsonHandler(http.StatusOK, infoOK), WithDebugWriter(&buf))
+
+	if _, err := api.Info("SECRETTOKEN"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	for _, want := range []string{"endpoint=info", "GET /api/info?access_token=REDACTED", `"success":true`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "SECRETTOKEN") || strings.Contains(out, "app-secret") {
+		t.Errorf("output contains credentials:\n%s", out)
+	}
+
+	if _, err := New("app-id", "app-secret", WithDebugWriter(nil)); err == nil {
+		t.Error("WithDebugWriter(nil) = nil error")
+	}
+}
@@ -0,0 +1,39 @@
+package clef
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MetricsObserver is called after every request to the Clef API. The
+// endpoint is the name of the called endpoint (authorize, info, logout or
+// swag) and is suitable as a metrics label, statusCode is 0 when no response
+// has been received.
+type MetricsObserver func(endpoint string, statusCode int, duration time.Duration)
+
+// WithMetrics registers observer to be called after every request, eg. to
+// feed a prometheus histogram:
+//
+//	clef.WithMetrics(func(endpoint string, statusCode int, d time.Duration) {
+//		histogram.WithLabelValues(endpoint, strconv.Itoa(statusCode)).Observe(d.Seconds())
+//	})
+func WithMetrics(observer MetricsObserver) Option {
+	return func(api *API) error {
+		api.metrics = observer
+		return nil
+	}
+}
+
+// endpoint returns the name of the endpoint req is sent to
+func (api *API) endpoint(req *http.Request) string {
+	return strings.Trim(strings.TrimPrefix(req.URL.Path, api.versionURL().Path), "/")
+}
+
+func (api *API) observe(req *http.Request, meta ResponseMeta) {
+	if api.metrics == nil {
+		return
+	}
+
+	api.metrics(api.endpoint(req), meta.StatusCode, meta.Duration)
+}
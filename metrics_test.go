@@ -0,0 +1,73 @@
+package clef
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithMetrics(t *testing.T) {
+	type observation struct {
+		endpoint string
+		status   int
+		duration time.Duration
+	}
+
+	var mu sync.Mutex
+	var observations []observation
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+
+		if strings.HasSuffix(r.URL.Path, "/authorize") {
+			jsonHandler(http.StatusForbidden, `{"error":"Invalid code."}`)(w, r)
+			return
+		}
+
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}, WithMetrics(func(endpoint string, statusCode int, d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		observations = append(observations, observation{endpoint, statusCode, d})
+	}))
+
+	api.Info("token")
+	api.Authorize("code")
+
+	want := []observation{
+		{"info", http.StatusOK, 10 * time.Millisecond},
+		{"authorize", http.StatusForbidden, 10 * time.Millisecond},
+	}
+
+	if len(observations) != len(want) {
+		t.Fatalf("observations = %+v, want %+v", observations, want)
+	}
+
+	for i, o := range observations {
+		if o.endpoint != want[i].endpoint || o.status != want[i].status || o.duration < want[i].duration {
+			t.Errorf("observation %d = %+v, want %+v (or longer)", i, o, want[i])
+		}
+	}
+}
+
+func TestWithMetricsNetworkError(t *testing.T) {
+	var status = -1
+
+	api, err := New("app-id", "app-secret", WithBaseURL("http://127.0.0.1:1/api/"), WithMetrics(func(endpoint string, statusCode int, d time.Duration) {
+		status = statusCode
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("token"); err == nil {
+		t.Fatal("expected network error")
+	}
+
+	if status != 0 {
+		t.Errorf("status = %d, want 0 without a response", status)
+	}
+}
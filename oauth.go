@@ -0,0 +1,190 @@
+package clef
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LoginOption configures the login url built by LoginURL
+type LoginOption func(url.Values)
+
+// WithState adds the OAuth state parameter to the login url
+func WithState(state string) LoginOption {
+	return func(v url.Values) {
+		v.Set("state", state)
+	}
+}
+
+// Scope is an OAuth scope, granting access to part of the user info
+type Scope string
+
+// The scopes supported by Clef
+const (
+	ScopeProfile Scope = "profile"
+	ScopeEmail   Scope = "email"
+	ScopePhone   Scope = "phone"
+)
+
+// WithScopes requests the OAuth scopes, the scope parameter is added to the
+// login url as a space separated list.
+func WithScopes(scopes ...Scope) LoginOption {
+	return func(v url.Values) {
+		s := make([]string, len(scopes))
+		for i, scope := range scopes {
+			s[i] = string(scope)
+		}
+
+		v.Set("scope", strings.Join(s, " "))
+	}
+}
+
+// WithStyle adds the style parameter to the login url
+func WithStyle(style string) LoginOption {
+	return func(v url.Values) {
+		v.Set("style", style)
+	}
+}
+
+// LoginURL returns the browser facing url that starts the Clef OAuth flow.
+// After login Clef redirects the user to redirectURL with the OAuth code.
+func (api *API) LoginURL(redirectURL string, opts ...LoginOption) string {
+	if api.ready() != nil {
+		return ""
+	}
+
+	query := url.Values{}
+	query.Set("app_id", api.appID())
+	query.Set("redirect_url", redirectURL)
+
+	for _, opt := range opts {
+		opt(query)
+	}
+
+	u := api.baseURL.ResolveReference(&url.URL{Path: "/oauth/authorize"})
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// LogoutParams returns the fields of a logout form or button, the
+// counterpart of LoginURL. The form posts the logout_token to the logout
+// handler of the application, which passes it on to Logout (see the
+// logoutHandler of the example). The application secret is never included.
+// No fields are returned when the API hasn't been initialized.
+func (api *API) LogoutParams(logoutToken string) url.Values {
+	params := url.Values{}
+	if api.ready() != nil {
+		return params
+	}
+
+	params.Set("app_id", api.appID())
+	params.Set("logout_token", logoutToken)
+	return params
+}
+
+// LoginURLWithState returns the login url with a freshly generated state
+// embedded. The state should be stored (eg. in a cookie) and verified with
+// VerifyState in the OAuth callback to protect against CSRF.
+func (api *API) LoginURLWithState(redirectURL string, opts ...LoginOption) (string, string, error) {
+	state, err := GenerateState()
+	if err != nil {
+		return "", "", err
+	}
+
+	opts = append(opts, WithState(state))
+	return api.LoginURL(redirectURL, opts...), state, nil
+}
+
+// GenerateState returns a random OAuth state value
+func GenerateState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// VerifyState compares the expected and received OAuth state in constant
+// time. An empty state never verifies.
+func VerifyState(expected, got string) bool {
+	if expected == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(got)) == 1
+}
+
+// OAuthError is the error reported to the OAuth callback instead of a code,
+// eg. when the user denies access
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+// Error implements error interface
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return "clef: oauth error: " + e.Code
+	}
+
+	return "clef: oauth error: " + e.Code + ": " + e.Description
+}
+
+// ParseCallbackError returns an *OAuthError when the OAuth callback request
+// r carries the error and error_description parameters, and nil otherwise.
+func ParseCallbackError(r *http.Request) error {
+	code := r.FormValue("error")
+	if code == "" {
+		return nil
+	}
+
+	return &OAuthError{
+		Code:        code,
+		Description: r.FormValue("error_description"),
+	}
+}
+
+// CallbackHandler returns a handler for the OAuth callback. It exchanges the
+// code form value for an access token and calls onSuccess, or onError when
+// the callback reports an *OAuthError, the code is missing or the exchange
+// fails. When onError is nil a plain error response is written.
+func (api *API) CallbackHandler(onSuccess func(w http.ResponseWriter, r *http.Request, ar *AuthorizeResponse), onError func(w http.ResponseWriter, r *http.Request, err error)) http.HandlerFunc {
+	if onError == nil {
+		onError = func(w http.ResponseWriter, r *http.Request, err error) {
+			var oauthErr *OAuthError
+			if errors.As(err, &oauthErr) && oauthErr.Code == "access_denied" {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			} else if oauthErr != nil || errors.Is(err, ErrEmptyCode) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ParseCallbackError(r); err != nil {
+			onError(w, r, err)
+			return
+		}
+
+		code := r.FormValue("code")
+		if code == "" {
+			onError(w, r, ErrEmptyCode)
+			return
+		}
+
+		if ar, err := api.AuthorizeContext(r.Context(), code); err != nil {
+			onError(w, r, err)
+		} else {
+			onSuccess(w, r, ar)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package clef
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseCallbackError(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  *OAuthError
+	}{
+		{"denied", "error=access_denied&error_description=The+user+denied+access", &OAuthError{Code: "access_denied", Description: "The user denied access"}},
+		{"without description", "error=server_error", &OAuthError{Code: "server_error"}},
+		{"success", "code=abc&state=xyz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseCallbackError(httptest.NewRequest("GET", "/callback?"+tt.query, nil))
+
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("ParseCallbackError() = %v, want nil", err)
+				}
+
+				return
+			}
+
+			var oe *OAuthError
+			if !errors.As(err, &oe) || *oe != *tt.want {
+				t.Errorf("ParseCallbackError() = %#v, want %#v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackHandler(t *testing.T) {
+	api := newTestAPI(t, jsonHandler(http.StatusOK, `{"success":true,"access_token":"t"}`))
+
+	handler := api.CallbackHandler(func(w http.ResponseWriter, r *http.Request, ar *AuthorizeResponse) {
+		http.Redirect(w, r, "/?token="+ar.AccessToken, http.StatusFound)
+	}, nil)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"success", "code=abc", http.StatusFound},
+		{"denied", "error=access_denied", http.StatusForbidden},
+		{"oauth error", "error=server_error", http.StatusBadRequest},
+		{"missing code", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/callback?"+tt.query, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+func TestLoginURL(t *testing.T) {
+	api, err := New("app-id", "app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(api.LoginURL("https://app/cb?next=/x", WithState("s"), WithScopes(ScopeEmail, ScopePhone)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := url.Values{
+		"app_id":       {"app-id"},
+		"redirect_url": {"https://app/cb?next=/x"},
+		"state":        {"s"},
+		"scope":        {"email phone"},
+	}
+
+	if u.Path != "/oauth/authorize" || u.Query().Encode() != want.Encode() {
+		t.Errorf("LoginURL() = %s, want /oauth/authorize?%s", u, want.Encode())
+	}
+}
+
+func TestVerifyState(t *testing.T) {
+	state, err := GenerateState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifyState(state, state) {
+		t.Error("VerifyState(state, state) = false")
+	}
+
+	if VerifyState(state, state+"x") || VerifyState("", "") {
+		t.Error("VerifyState accepted a mismatching or empty state")
+	}
+}
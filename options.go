@@ -0,0 +1,127 @@
+package clef
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures an API instance
+type Option func(*API) error
+
+// WithBaseURL overrides the Clef API base url, eg. to point the API to a
+// staging environment or a mock server.
+func WithBaseURL(u string) Option {
+	return func(api *API) error {
+		baseURL, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+
+		if baseURL.Scheme == "" || baseURL.Host == "" {
+			return fmt.Errorf("clef: invalid base url %q", u)
+		}
+
+		// endpoints are resolved relative to the base url
+		if !strings.HasSuffix(baseURL.Path, "/") {
+			baseURL.Path += "/"
+		}
+
+		api.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithAPIVersion sets the version of the Clef API to use, eg. "v2".
+// Endpoints are resolved relative to <base url>/<version>/. By default no
+// version is used and endpoints are resolved relative to the base url.
+func WithAPIVersion(v string) Option {
+	return func(api *API) error {
+		api.apiVersion = strings.Trim(v, "/")
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http client used for all requests. When client is
+// nil http.DefaultClient will be used. A custom client can't be combined
+// with the transport options (eg. WithProxy).
+func WithHTTPClient(client *http.Client) Option {
+	return func(api *API) error {
+		api.customClient = client != nil
+
+		if client == nil {
+			client = http.DefaultClient
+		}
+
+		api.Client = client
+		return nil
+	}
+}
+
+// WithLogger sets the logger used for debug output. By default nothing is
+// logged.
+func WithLogger(l Logger) Option {
+	return func(api *API) error {
+		if l == nil {
+			l = nopLogger{}
+		}
+
+		api.log = l
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(api *API) error {
+		api.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithAccept sets the Accept header sent with every request, defaults to
+// DefaultAccept. An empty accept omits the header.
+func WithAccept(accept string) Option {
+	return func(api *API) error {
+		api.accept = accept
+		return nil
+	}
+}
+
+// WithMaxResponseBytes limits the size of the response bodies that will be
+// read, defaults to DefaultMaxResponseBytes.
+func WithMaxResponseBytes(n int64) Option {
+	return func(api *API) error {
+		if n <= 0 {
+			return fmt.Errorf("clef: invalid max response bytes %d", n)
+		}
+
+		api.maxResponseBytes = n
+		return nil
+	}
+}
+
+// RefreshFunc exchanges an expired access token for a new one, eg. using a
+// stored refresh token
+type RefreshFunc func(expired string) (string, error)
+
+// WithAutoRefresh makes Info refresh an expired access token using refresh
+// and retry once. The new token is returned in InfoResponse.RefreshedToken,
+// so the caller can update the session.
+func WithAutoRefresh(refresh RefreshFunc) Option {
+	return func(api *API) error {
+		api.refresh = refresh
+		return nil
+	}
+}
+
+// WithStrictDecoding makes decoding fail when a response contains fields
+// that aren't modeled by the response types. It is intended for integration
+// tests, to notice changes of the Clef API early.
+func WithStrictDecoding() Option {
+	return func(api *API) error {
+		api.strictDecoding = true
+		return nil
+	}
+}
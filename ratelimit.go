@@ -0,0 +1,51 @@
+package clef
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit contains the rate limit state as reported by the Clef API
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// parseRateLimit parses the X-RateLimit-* headers, ok is false when the
+// response doesn't carry any of them.
+func parseRateLimit(h http.Header) (rl RateLimit, ok bool) {
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		rl.Limit = v
+		ok = true
+	}
+
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		rl.Remaining = v
+		ok = true
+	}
+
+	if v, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(v, 0)
+		ok = true
+	}
+
+	return rl, ok
+}
+
+// LastRateLimit returns the rate limit state of the most recent response
+// that reported one.
+func (api *API) LastRateLimit() RateLimit {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	return api.rateLimit
+}
+
+func (api *API) setRateLimit(rl RateLimit) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.rateLimit = rl
+}
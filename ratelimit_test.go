@@ -0,0 +1,57 @@
+package clef
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   RateLimit
+		ok     bool
+	}{
+		{"all headers", http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"42"},
+			"X-Ratelimit-Reset":     {"1700000000"},
+		}, RateLimit{Limit: 100, Remaining: 42, Reset: time.Unix(1700000000, 0)}, true},
+		{"remaining only", http.Header{"X-Ratelimit-Remaining": {"0"}}, RateLimit{}, true},
+		{"invalid", http.Header{"X-Ratelimit-Limit": {"many"}}, RateLimit{}, false},
+		{"none", http.Header{}, RateLimit{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRateLimit(tt.header)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: parseRateLimit() = %+v, %t, want %+v, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLastRateLimit(t *testing.T) {
+	h, _ := sequence(
+		withHeader("X-RateLimit-Remaining", "9", withHeader("X-RateLimit-Limit", "10", jsonHandler(http.StatusOK, infoOK))),
+		jsonHandler(http.StatusOK, infoOK),
+	)
+
+	api := newTestAPI(t, h)
+
+	if got := api.LastRateLimit(); got != (RateLimit{}) {
+		t.Errorf("LastRateLimit() = %+v before any request, want zero value", got)
+	}
+
+	want := RateLimit{Limit: 10, Remaining: 9}
+	for i := 0; i < 2; i++ {
+		if _, err := api.Info("token"); err != nil {
+			t.Fatal(err)
+		}
+
+		// responses without rate limit headers keep the last state
+		if got := api.LastRateLimit(); got != want {
+			t.Errorf("request %d: LastRateLimit() = %+v, want %+v", i+1, got, want)
+		}
+	}
+}
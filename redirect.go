@@ -0,0 +1,73 @@
+package clef
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrRedirectNotAllowed will be returned by ValidateRedirect when the
+// target doesn't match any of the allowed redirects
+var ErrRedirectNotAllowed = errors.New("clef: redirect not allowed")
+
+// ValidateRedirect checks that raw, eg. the redirect parameter of a login
+// handler, points to one of the allowed targets and returns the cleaned url
+// to redirect to. This prevents the handler being used as an open redirect.
+//
+// An allowed target is either a path, like "/account", which matches
+// relative urls, or a host with an optional path, like "example.com" or
+// "example.com/app", which matches http and https urls. Paths match
+// themselves and everything below them.
+func ValidateRedirect(raw string, allowed []string) (string, error) {
+	// browsers treat backslashes as slashes, "/\evil.com" is "//evil.com"
+	if raw == "" || strings.ContainsAny(raw, "\\\r\n\t") {
+		return "", ErrRedirectNotAllowed
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ErrRedirectNotAllowed
+	}
+
+	relative := u.Scheme == "" && u.Host == "" && u.User == nil
+	if relative && !strings.HasPrefix(u.Path, "/") {
+		return "", ErrRedirectNotAllowed
+	} else if !relative && u.Scheme != "http" && u.Scheme != "https" {
+		return "", ErrRedirectNotAllowed
+	} else if u.User != nil {
+		return "", ErrRedirectNotAllowed
+	}
+
+	p := "/"
+	if u.Path != "" {
+		p = path.Clean(u.Path)
+	}
+
+	for _, a := range allowed {
+		if strings.HasPrefix(a, "/") {
+			if relative && pathWithin(p, a) {
+				return u.String(), nil
+			}
+
+			continue
+		}
+
+		host, prefix := a, ""
+		if i := strings.Index(a, "/"); i >= 0 {
+			host, prefix = a[:i], a[i:]
+		}
+
+		if !relative && strings.EqualFold(u.Host, host) && pathWithin(p, prefix) {
+			return u.String(), nil
+		}
+	}
+
+	return "", ErrRedirectNotAllowed
+}
+
+// pathWithin returns true if p equals prefix or is below it
+func pathWithin(p, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
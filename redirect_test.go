@@ -0,0 +1,40 @@
+package clef
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRedirect(t *testing.T) {
+	allowed := []string{"/account", "example.com", "app.example.com/cb"}
+
+	tests := []struct {
+		raw string
+		ok  bool
+	}{
+		{"/account", true},
+		{"/account/settings?tab=1", true},
+		{"https://example.com/x", true},
+		{"http://EXAMPLE.com", true},
+		{"https://app.example.com/cb/1", true},
+		{"/accountx", false},
+		{"/account/../admin", false},
+		{"account", false},
+		{"", false},
+		{"//evil.com", false},
+		{"/\\evil.com", false},
+		{"https://evil.com", false},
+		{"https://app.example.com/other", false},
+		{"https://u@example.com/", false},
+		{"javascript:alert(1)", false},
+	}
+
+	for _, tt := range tests {
+		got, err := ValidateRedirect(tt.raw, allowed)
+		if tt.ok && (err != nil || got == "") {
+			t.Errorf("ValidateRedirect(%q) = %q, %v, want allowed", tt.raw, got, err)
+		} else if !tt.ok && !errors.Is(err, ErrRedirectNotAllowed) {
+			t.Errorf("ValidateRedirect(%q) = %q, %v, want ErrRedirectNotAllowed", tt.raw, got, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxRetryAfter is the longest Retry-After delay that is waited for
+// before retrying, see WithMaxRetryAfter
+const DefaultMaxRetryAfter = time.Minute
+
+// WithRetry retries idempotent requests (GET requests and requests with an
+// Idempotency-Key header) up to maxAttempts times in total when they fail
+// with a network error, a 5xx response or a 429 response with a Retry-After
+// header. The delay between attempts grows exponentially from baseDelay and
+// is jittered, unless the response requested a delay using Retry-After.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(api *API) error {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+
+		api.maxAttempts = maxAttempts
+		api.retryBaseDelay = baseDelay
+		return nil
+	}
+}
+
+// WithMaxRetryAfter sets the longest delay requested by a Retry-After header
+// that is waited for before retrying, DefaultMaxRetryAfter by default. When
+// a response requests a longer delay, its error is returned right away, so
+// the caller can decide to try again later using Error.RetryAfter.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(api *API) error {
+		api.maxRetryAfter = d
+		return nil
+	}
+}
+
+// shouldRetry returns true if the failed attempt of req may be retried
+func (api *API) shouldRetry(req *http.Request, err error, attempt int) bool {
+	if attempt >= api.maxAttempts {
+		return false
+	}
+
+	// requests carrying an idempotency key are safe to repeat
+	if req.Method != http.MethodGet && req.Header.Get("Idempotency-Key") == "" {
+		return false
+	}
+
+	if req.Context().Err() != nil || !isTransient(err) {
+		return false
+	}
+
+	if retryAfter(err) > api.maxRetryAfter {
+		return false
+	}
+
+	return takeRetry(req.Context())
+}
+
+// isTransient returns true if err is a network error, a 5xx response or a
+// 429 response with a Retry-After header
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return true
+	}
+
+	var e *Error
+	if errors.As(err, &e) && e.StatusCode == http.StatusTooManyRequests {
+		return e.RetryAfter > 0
+	}
+
+	return IsServerError(err)
+}
+
+// retryDelay returns the delay before retrying after err, which is the delay
+// requested by a 429 or 503 response or the backoff of the attempt.
+func (api *API) retryDelay(err error, attempt int) time.Duration {
+	if d := retryAfter(err); d > 0 {
+		return d
+	}
+
+	return api.backoff(attempt)
+}
+
+// retryAfter returns the delay requested by err, if it is caused by a 429 or
+// 503 response with a Retry-After header
+func retryAfter(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) &&
+		(e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable) {
+		return e.RetryAfter
+	}
+
+	return 0
+}
+
+// backoff returns the jittered delay before the next attempt
+func (api *API) backoff(attempt int) time.Duration {
+	delay := api.retryBaseDelay << uint(attempt-1)
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// waitRetry waits d before the next attempt. When the deadline of ctx would
+// pass while waiting, it returns context.DeadlineExceeded right away instead
+// of sleeping for nothing.
+func (api *API) waitRetry(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && api.clock.Now().Add(d).After(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return api.clock.Sleep(ctx, d)
+}
+
+// rewind returns a copy of req with a fresh body, ready to be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return r, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = body
+	return r, nil
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a copy of ctx carrying a budget of n retries that
+// is shared by all Clef calls made with the context (or contexts derived
+// from it), preventing retry storms in chained calls. The budget only limits
+// retries: every call still makes its first attempt, and WithRetry still
+// caps the attempts of a single call.
+func WithRetryBudget(ctx context.Context, n int) context.Context {
+	budget := int64(n)
+	return context.WithValue(ctx, retryBudgetKey{}, &budget)
+}
+
+// takeRetry consumes a retry from the budget of ctx, it returns false when
+// the budget is exhausted.
+func takeRetry(ctx context.Context) bool {
+	budget, ok := ctx.Value(retryBudgetKey{}).(*int64)
+	if !ok {
+		return true
+	}
+
+	return atomic.AddInt64(budget, -1) >= 0
+}
@@ -0,0 +1,332 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sequence returns a handler serving the handlers in order, repeating the
+// last one, and the number of requests served
+func sequence(handlers ...http.HandlerFunc) (http.HandlerFunc, *int32) {
+	n := new(int32)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(n, 1)) - 1
+		if i >= len(handlers) {
+			i = len(handlers) - 1
+		}
+
+		handlers[i](w, r)
+	}, n
+}
+
+// dropConnection closes the connection without a response
+func dropConnection(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func withHeader(key, value string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(key, value)
+		h(w, r)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	unavailable := jsonHandler(http.StatusServiceUnavailable, `{"error":"Service unavailable."}`)
+
+	tests := []struct {
+		name     string
+		handlers []http.HandlerFunc
+		call     func(api *API) error
+		requests int32
+		ok       bool
+	}{
+		{"5xx is retried", []http.HandlerFunc{unavailable, jsonHandler(http.StatusOK, infoOK)}, func(api *API) error {
+			_, err := api.Info("token")
+			return err
+		}, 2, true},
+		{"network error is retried", []http.HandlerFunc{dropConnection, jsonHandler(http.StatusOK, infoOK)}, func(api *API) error {
+			_, err := api.Info("token")
+			return err
+		}, 2, true},
+		{"gives up after max attempts", []http.HandlerFunc{unavailable}, func(api *API) error {
+			_, err := api.Info("token")
+			return err
+		}, 3, false},
+		{"4xx is not retried", []http.HandlerFunc{jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`)}, func(api *API) error {
+			_, err := api.Info("token")
+			return err
+		}, 1, false},
+		{"post is not retried", []http.HandlerFunc{unavailable, jsonHandler(http.StatusOK, `{"success":true,"access_token":"t"}`)}, func(api *API) error {
+			_, err := api.Authorize("code")
+			return err
+		}, 1, false},
+		{"post with idempotency key is retried", []http.HandlerFunc{unavailable, jsonHandler(http.StatusOK, `{"success":true}`)}, func(api *API) error {
+			_, err := api.Swag(&SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"})
+			return err
+		}, 2, true},
+		{"cancelled context is not retried", []http.HandlerFunc{unavailable, jsonHandler(http.StatusOK, infoOK)}, func(api *API) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := api.InfoContext(ctx, "token")
+			return err
+		}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, requests := sequence(tt.handlers...)
+			clock := newFakeClock()
+			api := newTestAPI(t, h, WithRetry(3, 100*time.Millisecond), WithClock(clock))
+
+			err := tt.call(api)
+			if (err == nil) != tt.ok {
+				t.Errorf("error = %v, want success %t", err, tt.ok)
+			}
+
+			if got := atomic.LoadInt32(requests); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+
+			for i, d := range clock.Sleeps() {
+				max := 100 * time.Millisecond << uint(i)
+				if d < max/2 || d > max {
+					t.Errorf("backoff %d = %s, want between %s and %s", i+1, d, max/2, max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryKeepsIdempotencyKey(t *testing.T) {
+	var keys []string
+
+	h, _ := sequence(
+		jsonHandler(http.StatusServiceUnavailable, `{"error":"Service unavailable."}`),
+		jsonHandler(http.StatusOK, `{"success":true}`),
+	)
+
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		h(w, r)
+	}, WithRetry(2, 0))
+
+	if _, err := api.Swag(&SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("idempotency keys = %q, want the same key for both attempts", keys)
+	}
+
+	if _, err := api.Swag(&SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: "NL"}, WithIdempotencyKey("order-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := keys[len(keys)-1]; got != "order-1" {
+		t.Errorf("idempotency key = %q, want order-1", got)
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	api := newTestAPI(t, jsonHandler(http.StatusServiceUnavailable, `{"error":"Service unavailable."}`), WithRetry(3, 10*time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.InfoContext(ctx, "token"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("returned after %s, want it to return before the backoff delay", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	clock := newFakeClock()
+
+	tests := []struct {
+		name  string
+		first func() http.HandlerFunc
+		want  time.Duration
+	}{
+		{"429 seconds", func() http.HandlerFunc {
+			return withHeader("Retry-After", "7", jsonHandler(http.StatusTooManyRequests, `{"error":"Rate limit exceeded."}`))
+		}, 7 * time.Second},
+		{"503 seconds", func() http.HandlerFunc {
+			return withHeader("Retry-After", "45", jsonHandler(http.StatusServiceUnavailable, `{"error":"Maintenance."}`))
+		}, 45 * time.Second},
+		{"429 http date", func() http.HandlerFunc {
+			date := clock.Now().Add(30 * time.Second).Format(http.TimeFormat)
+			return withHeader("Retry-After", date, jsonHandler(http.StatusTooManyRequests, `{"error":"Rate limit exceeded."}`))
+		}, 30 * time.Second},
+		{"503 http date", func() http.HandlerFunc {
+			date := clock.Now().Add(50 * time.Second).Format(http.TimeFormat)
+			return withHeader("Retry-After", date, jsonHandler(http.StatusServiceUnavailable, `{"error":"Maintenance."}`))
+		}, 50 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.mu.Lock()
+			clock.sleeps = nil
+			clock.mu.Unlock()
+
+			h, requests := sequence(tt.first(), jsonHandler(http.StatusOK, infoOK))
+			api := newTestAPI(t, h, WithRetry(2, time.Millisecond), WithClock(clock))
+
+			if _, err := api.Info("token"); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := atomic.LoadInt32(requests); got != 2 {
+				t.Errorf("requests = %d, want 2", got)
+			}
+
+			if sleeps := clock.Sleeps(); len(sleeps) != 1 || sleeps[0] != tt.want {
+				t.Errorf("sleeps = %v, want [%s]", sleeps, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		requests int32
+		sleeps   int
+	}{
+		{"over the default maximum", nil, 1, 0},
+		{"over the configured maximum", []Option{WithMaxRetryAfter(time.Hour)}, 1, 0},
+		{"within the configured maximum", []Option{WithMaxRetryAfter(48 * time.Hour)}, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+
+			h, requests := sequence(
+				withHeader("Retry-After", "86400", jsonHandler(http.StatusServiceUnavailable, `{"error":"Maintenance."}`)),
+				jsonHandler(http.StatusOK, infoOK),
+			)
+
+			api := newTestAPI(t, h, append([]Option{WithRetry(2, 0), WithClock(clock)}, tt.opts...)...)
+
+			_, err := api.Info("token")
+			if tt.requests == 1 {
+				var e *Error
+				if !errors.As(err, &e) || e.RetryAfter != 24*time.Hour {
+					t.Errorf("error = %v, want *Error with RetryAfter 24h", err)
+				}
+			} else if err != nil {
+				t.Errorf("error = %v", err)
+			}
+
+			if got := atomic.LoadInt32(requests); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+
+			if got := len(clock.Sleeps()); got != tt.sleeps {
+				t.Errorf("sleeps = %d, want %d", got, tt.sleeps)
+			}
+		})
+	}
+}
+
+func TestRateLimitWithoutRetryAfterIsNotRetried(t *testing.T) {
+	h, requests := sequence(jsonHandler(http.StatusTooManyRequests, `{"error":"Rate limit exceeded."}`), jsonHandler(http.StatusOK, infoOK))
+	api := newTestAPI(t, h, WithRetry(3, 0))
+
+	if _, err := api.Info("token"); !IsRateLimitError(err) {
+		t.Errorf("error = %v, want rate limit error", err)
+	}
+
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-5", 0},
+		{"30", 30 * time.Second},
+		{" 30 ", 30 * time.Second},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"Fri, 01 Jan 2016 00:00:10 UTC", 0},
+		{"tomorrow", 0},
+	}
+
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Retry-After", tt.value)
+
+		if got := parseRetryAfter(h, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestServiceUnavailable(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", 0},
+		{"60", time.Minute},
+	}
+
+	for _, tt := range tests {
+		h := jsonHandler(http.StatusServiceUnavailable, `{"error":"Maintenance."}`)
+		if tt.retryAfter != "" {
+			h = withHeader("Retry-After", tt.retryAfter, h)
+		}
+
+		api := newTestAPI(t, h)
+
+		_, err := api.Info("token")
+		if !IsServiceUnavailableError(err) {
+			t.Fatalf("error = %v, want service unavailable error", err)
+		}
+
+		var e *Error
+		if !errors.As(err, &e) || e.RetryAfter != tt.want {
+			t.Errorf("RetryAfter = %v, want %s", e, tt.want)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	h, requests := sequence(jsonHandler(http.StatusServiceUnavailable, `{"error":"Service unavailable."}`))
+	api := newTestAPI(t, h, WithRetry(3, 0))
+
+	ctx := WithRetryBudget(context.Background(), 1)
+	for i := 0; i < 2; i++ {
+		api.InfoContext(ctx, "token"+strconv.Itoa(i))
+	}
+
+	// two first attempts and the single retry of the budget
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
@@ -0,0 +1,83 @@
+package clef
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNoSession will be returned when a request doesn't carry a session
+var ErrNoSession = errors.New("clef: no session")
+
+// SessionManager ties the access token, stored in a cookie, to the info of
+// the logged in Clef user.
+type SessionManager struct {
+	api *API
+
+	// CookieName is the name of the cookie holding the access token
+	CookieName string
+
+	// Path is the path of the cookie
+	Path string
+
+	// Secure restricts the cookie to https connections
+	Secure bool
+
+	// SameSite is the SameSite attribute of the cookie
+	SameSite http.SameSite
+}
+
+// NewSessionManager returns a session manager for api, storing the access
+// token in an access_token cookie. The cookie is always HttpOnly and
+// defaults to Secure and SameSite=Lax; cookies are removed with MaxAge -1.
+func NewSessionManager(api *API) *SessionManager {
+	return &SessionManager{
+		api:        api,
+		CookieName: "access_token",
+		Path:       "/",
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+}
+
+func (sm *SessionManager) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     sm.CookieName,
+		Value:    value,
+		Path:     sm.Path,
+		Secure:   sm.Secure,
+		HttpOnly: true,
+		SameSite: sm.SameSite,
+	}
+}
+
+// Login starts the session for the authorized user
+func (sm *SessionManager) Login(w http.ResponseWriter, ar *AuthorizeResponse) {
+	http.SetCookie(w, sm.cookie(ar.AccessToken))
+}
+
+// Current returns the info of the user of the session of r, or ErrNoSession
+// when there is no session.
+func (sm *SessionManager) Current(r *http.Request) (*InfoStruct, error) {
+	c, err := r.Cookie(sm.CookieName)
+	if err != nil || c.Value == "" {
+		return nil, ErrNoSession
+	}
+
+	ir, err := sm.api.InfoContext(r.Context(), c.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return ir.Info, nil
+}
+
+// Logout ends the session of r
+func (sm *SessionManager) Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sm.CookieName); err == nil {
+		sm.api.InvalidateInfo(c.Value)
+	}
+
+	c := sm.cookie("")
+	c.MaxAge = -1
+	http.SetCookie(w, c)
+}
@@ -0,0 +1,45 @@
+package clef
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionCookies(t *testing.T) {
+	api := newTestAPI(t, jsonHandler(http.StatusOK, `{"success":true,"info":{"id":7,"email":"jane@example.com"}}`))
+	sm := NewSessionManager(api)
+
+	w := httptest.NewRecorder()
+	sm.Login(w, &AuthorizeResponse{AccessToken: "token"})
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %v, want 1", cookies)
+	}
+
+	c := cookies[0]
+	if c.Name != "access_token" || c.Value != "token" || !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode || c.Path != "/" {
+		t.Errorf("login cookie = %+v, want a HttpOnly, Secure, SameSite=Lax access_token cookie", c)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(c)
+
+	info, err := sm.Current(r)
+	if err != nil || info.ID != 7 {
+		t.Errorf("Current() = %+v, %v, want the session user", info, err)
+	}
+
+	if _, err := sm.Current(httptest.NewRequest("GET", "/", nil)); err != ErrNoSession {
+		t.Errorf("Current() without cookie = %v, want ErrNoSession", err)
+	}
+
+	w = httptest.NewRecorder()
+	sm.Logout(w, r)
+
+	cookies = w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != -1 || cookies[0].Value != "" || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Errorf("logout cookies = %+v, want an expired secure cookie", cookies)
+	}
+}
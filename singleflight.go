@@ -0,0 +1,74 @@
+package clef
+
+import (
+	"context"
+	"sync"
+)
+
+// WithSingleFlight coalesces concurrent Info calls for the same access
+// token into a single request to the Clef API. Callers waiting for an
+// in-flight request share its result, including a cancellation of the
+// context of the caller that started it. A waiting caller whose own context
+// is done stops waiting and returns the error of its context.
+func WithSingleFlight() Option {
+	return func(api *API) error {
+		api.flights = &flightGroup{}
+		return nil
+	}
+}
+
+type flightCall struct {
+	done chan struct{}
+	ir   *InfoResponse
+	meta ResponseMeta
+	err  error
+}
+
+// flightGroup deduplicates concurrent calls by key, like
+// golang.org/x/sync/singleflight
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*InfoResponse, ResponseMeta, error)) (*InfoResponse, ResponseMeta, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ResponseMeta{}, ctx.Err()
+		}
+
+		if c.ir == nil {
+			return nil, c.meta, c.err
+		}
+
+		// every caller gets its own copy of the response
+		return c.ir.clone(), c.meta, c.err
+	}
+
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	ir, meta, err := fn()
+	if ir != nil {
+		c.ir = ir.clone()
+	}
+
+	c.meta, c.err = meta, err
+	close(c.done)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return ir, meta, err
+}
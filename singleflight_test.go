@@ -0,0 +1,101 @@
+package clef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingInfoAPI returns an API with single flight enabled whose info
+// requests block until release is closed
+func blockingInfoAPI(t *testing.T) (api *API, requests *int32, arrived <-chan struct{}, release chan struct{}) {
+	requests = new(int32)
+	arrivedc := make(chan struct{}, 1)
+	release = make(chan struct{})
+
+	api = newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		arrivedc <- struct{}{}
+		<-release
+		jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1}}`)(w, r)
+	}, WithSingleFlight())
+
+	return api, requests, arrivedc, release
+}
+
+func TestSingleFlightCoalesces(t *testing.T) {
+	api, requests, arrived, release := blockingInfoAPI(t)
+
+	const n = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	infos := make(chan *InfoStruct, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if ir, err := api.Info("token"); err != nil {
+				errs <- err
+			} else if ir.Info.ID != 1 {
+				errs <- errors.New("unexpected response")
+			} else {
+				infos <- ir.Info
+			}
+		}()
+	}
+
+	<-arrived
+	// give the other callers time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+	close(errs)
+
+	close(infos)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// every caller gets its own copy of the response
+	seen := map[*InfoStruct]bool{}
+	for info := range infos {
+		if seen[info] {
+			t.Fatal("callers share the same InfoStruct")
+		}
+
+		seen[info] = true
+	}
+
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("upstream requests = %d, want 1", got)
+	}
+}
+
+func TestSingleFlightWaiterHonorsContext(t *testing.T) {
+	api, _, arrived, release := blockingInfoAPI(t)
+	defer close(release)
+
+	go api.Info("token")
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.InfoContext(ctx, "token"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("InfoContext() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("waiter returned after %s, want it to return at its deadline", d)
+	}
+}
@@ -0,0 +1,59 @@
+package clef
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// ValidationError lists the problems found while validating a request
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements error interface
+func (e *ValidationError) Error() string {
+	return "clef: invalid request: " + strings.Join(e.Problems, ", ")
+}
+
+// Validate checks the required fields of the swag request are set and the
+// email address and country are valid. The country is normalized to its
+// ISO 3166-1 alpha-2 code.
+func (r *SwagRequest) Validate() error {
+	problems := []string{}
+
+	required := []struct {
+		name, value string
+	}{
+		{"name", r.Name},
+		{"email", r.Email},
+		{"address_line_1", r.AddressLine1},
+		{"city", r.City},
+		{"country", r.Country},
+	}
+
+	for _, f := range required {
+		if strings.TrimSpace(f.value) == "" {
+			problems = append(problems, f.name+" is required")
+		}
+	}
+
+	if r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			problems = append(problems, "email is invalid")
+		}
+	}
+
+	if strings.TrimSpace(r.Country) != "" {
+		if country, ok := Country(r.Country).Normalize(); ok {
+			r.Country = string(country)
+		} else {
+			problems = append(problems, "country is invalid")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
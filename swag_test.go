@@ -0,0 +1,105 @@
+package clef
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSwagFormEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		req  SwagRequest
+	}{
+		{"plus and ampersand", SwagRequest{
+			Name:         "Jane+John & Co",
+			Email:        "jane+swag@example.com",
+			AddressLine1: "1 A&B Street",
+			City:         "New York",
+			Country:      "US",
+		}},
+		{"utf-8", SwagRequest{
+			Name:         "Zoë Çelik",
+			Email:        "zoe@example.com",
+			AddressLine1: "Straße 5, 3ᵉ étage",
+			AddressLine2: "東京都",
+			City:         "Zürich",
+			Country:      "CH",
+		}},
+		{"reserved characters", SwagRequest{
+			Name:         "100% = a=b?c#d",
+			Email:        "x@example.com",
+			AddressLine1: "  leading and trailing spaces  ",
+			City:         "a/b;c",
+			ZipCode:      "%20",
+			Country:      "NL",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+
+			api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Error(err)
+				}
+
+				got = map[string]string{}
+				for k := range r.PostForm {
+					got[k] = r.PostForm.Get(k)
+				}
+
+				jsonHandler(http.StatusOK, `{"success":true}`)(w, r)
+			})
+
+			req := tt.req
+			if _, err := api.Swag(&req); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{
+				"name":           tt.req.Name,
+				"email":          tt.req.Email,
+				"address_line_1": tt.req.AddressLine1,
+				"address_line_2": tt.req.AddressLine2,
+				"city":           tt.req.City,
+				"zip_code":       tt.req.ZipCode,
+				"state":          tt.req.State,
+				"country":        tt.req.Country,
+			}
+
+			for k, v := range want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestSwagRequestValidateCountry(t *testing.T) {
+	tests := []struct {
+		country string
+		want    string
+		valid   bool
+	}{
+		{"us", "US", true},
+		{"USA", "US", true},
+		{"Holland", "NL", true},
+		{"USofA", "USofA", false},
+	}
+
+	for _, tt := range tests {
+		country := tt.country
+		req := SwagRequest{Name: "Jane", Email: "jane@example.com", AddressLine1: "Street 1", City: "City", Country: country}
+
+		err := req.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate() with country %q = %v, want valid %t", tt.country, err, tt.valid)
+		}
+
+		if req.Country != tt.want {
+			t.Errorf("Country = %q, want %q", req.Country, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package clef
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer creates spans around Clef API requests. It is a minimal interface
+// so an adapter for eg. OpenTelemetry can be plugged in without adding
+// dependencies to this package.
+type Tracer interface {
+	// Start starts a span named name as child of the span in ctx
+	Start(ctx context.Context, name string) (context.Context, Span)
+
+	// Inject propagates the trace of ctx into the outgoing request headers
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// WithTracer wraps every request in a span named clef.<endpoint>
+func WithTracer(tracer Tracer) Option {
+	return func(api *API) error {
+		api.tracer = tracer
+		return nil
+	}
+}
+
+// attempt executes req once, traced when a tracer has been configured
+func (api *API) attempt(req *http.Request, v interface{}) (ResponseMeta, error) {
+	if api.tracer == nil {
+		return api.send(req, v)
+	}
+
+	ctx, span := api.tracer.Start(req.Context(), "clef."+api.endpoint(req))
+	defer span.End()
+
+	req = req.Clone(ctx)
+	api.tracer.Inject(ctx, req.Header)
+
+	meta, err := api.send(req, v)
+	if meta.StatusCode != 0 {
+		span.SetAttribute("http.status_code", meta.StatusCode)
+	}
+
+	if err != nil {
+		span.SetAttribute("error", redactError(err))
+	}
+
+	return meta, err
+}
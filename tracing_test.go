@@ -0,0 +1,76 @@
+package clef
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// fakeTracer records the spans it started
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+type fakeSpanKey struct{}
+
+type fakeSpan struct {
+	name       string
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &fakeSpan{name: name, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, fakeSpanKey{}, s), s
+}
+
+func (t *fakeTracer) Inject(ctx context.Context, header http.Header) {
+	if s, ok := ctx.Value(fakeSpanKey{}).(*fakeSpan); ok {
+		header.Set("Traceparent", s.name)
+	}
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *fakeSpan) End() {
+	s.ended = true
+}
+
+func TestWithTracer(t *testing.T) {
+	var traceparent string
+
+	tracer := &fakeTracer{}
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`)(w, r)
+	}, WithTracer(tracer))
+
+	if _, err := api.Info("token"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(tracer.spans))
+	}
+
+	s := tracer.spans[0]
+	if s.name != "clef.info" || !s.ended {
+		t.Errorf("span = %+v, want an ended clef.info span", s)
+	}
+
+	if s.attributes["http.status_code"] != http.StatusForbidden || s.attributes["error"] == nil {
+		t.Errorf("attributes = %v, want status code and error", s.attributes)
+	}
+
+	if traceparent != "clef.info" {
+		t.Errorf("Traceparent = %q, want the span injected into the request", traceparent)
+	}
+}
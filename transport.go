@@ -0,0 +1,155 @@
+package clef
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrConflictingOptions will be returned by New when transport options are
+// combined with WithHTTPClient. Transport options only apply to the default
+// transport, configure the transport of a custom client directly instead.
+var ErrConflictingOptions = errors.New("clef: transport options can't be combined with WithHTTPClient")
+
+// transport returns the transport that is being configured by the transport
+// options, a clone of http.DefaultTransport. By default it uses the proxy
+// configured in the environment, see http.ProxyFromEnvironment.
+func (api *API) transport() *http.Transport {
+	if api.defaultTransport == nil {
+		api.defaultTransport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	return api.defaultTransport
+}
+
+// applyTransport installs the configured transport, it is called after all
+// options have been applied.
+func (api *API) applyTransport() error {
+	if api.defaultTransport == nil {
+		return nil
+	}
+
+	if api.customClient {
+		return ErrConflictingOptions
+	}
+
+	api.Client = &http.Client{Transport: api.defaultTransport}
+	return nil
+}
+
+// WithProxy routes all requests through the proxy at proxyURL. Without it
+// the proxy configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables is used.
+func WithProxy(proxyURL string) Option {
+	return func(api *API) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return err
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("clef: invalid proxy url %q", proxyURL)
+		}
+
+		api.transport().Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default transport, eg. to
+// pin certificates or restrict TLS versions. Like all transport options it
+// can't be combined with WithHTTPClient; New returns ErrConflictingOptions
+// rather than silently ignoring the configuration.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(api *API) error {
+		if config == nil {
+			return errors.New("clef: nil tls config")
+		}
+
+		api.transport().TLSClientConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithDialTimeout limits the time spent establishing a connection to d,
+// independent of the overall request timeout, so an unreachable API fails
+// fast.
+func WithDialTimeout(d time.Duration) Option {
+	return func(api *API) error {
+		if d <= 0 {
+			return fmt.Errorf("clef: invalid dial timeout %s", d)
+		}
+
+		dialer := &net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}
+
+		api.transport().DialContext = dialer.DialContext
+		return nil
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle (keep-alive) connections
+// of the default transport, both in total and per host. It only applies to
+// the default transport and can't be combined with WithHTTPClient.
+func WithMaxIdleConns(n int) Option {
+	return func(api *API) error {
+		if n < 0 {
+			return fmt.Errorf("clef: invalid max idle conns %d", n)
+		}
+
+		t := api.transport()
+		t.MaxIdleConns = n
+		t.MaxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets how long idle connections of the default
+// transport are kept open. It only applies to the default transport and
+// can't be combined with WithHTTPClient.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(api *API) error {
+		if d < 0 {
+			return fmt.Errorf("clef: invalid idle conn timeout %s", d)
+		}
+
+		api.transport().IdleConnTimeout = d
+		return nil
+	}
+}
+
+// WithDisableKeepAlives closes the connection after every request, so short
+// lived programs, like command line tools, don't leave idle connections
+// open. It only applies to the default transport and can't be combined with
+// WithHTTPClient.
+func WithDisableKeepAlives() Option {
+	return func(api *API) error {
+		api.transport().DisableKeepAlives = true
+		return nil
+	}
+}
+
+// WithForceHTTP2 makes the default transport speak HTTP/2 only, even when
+// it has been customized by other transport options such as WithTLSConfig.
+// Concurrent requests are multiplexed over a single connection to the API,
+// avoiding repeated TLS handshakes under load. The tradeoffs are that all
+// requests share the fate of that connection, a stalled connection delays
+// all of them, and that requests fail rather than fall back to HTTP/1.1
+// when the server (or a proxy in between) doesn't support HTTP/2 over TLS.
+func WithForceHTTP2() Option {
+	return func(api *API) error {
+		protocols := &http.Protocols{}
+		protocols.SetHTTP2(true)
+
+		t := api.transport()
+		t.Protocols = protocols
+		t.ForceAttemptHTTP2 = true
+		return nil
+	}
+}
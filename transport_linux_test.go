@@ -0,0 +1,69 @@
+//go:build linux
+
+package clef
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fullBacklogListener returns the address of a listener that never accepts
+// and whose accept queue is full, so connecting to it hangs
+func fullBacklogListener(t *testing.T) string {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { syscall.Close(fd) })
+
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	} else if err := syscall.Listen(fd, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	sa, err := syscall.Getsockname(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", sa.(*syscall.SockaddrInet4).Port)
+
+	// fill the accept queue
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+	return addr
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	api, err := New("app-id", "app-secret", WithBaseURL("http://"+fullBacklogListener(t)+"/api/"), WithDialTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	_, err = api.Info("token")
+
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("Info() error = %v, want a dial timeout", err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("dial failed after %s, want it to fail after the dial timeout", d)
+	}
+
+	if _, err := New("app-id", "app-secret", WithDialTimeout(0)); err == nil {
+		t.Error("WithDialTimeout(0) = nil error")
+	}
+}
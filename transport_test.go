@@ -0,0 +1,229 @@
+package clef
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportOptions(t *testing.T) {
+	api, err := New("app-id", "app-secret", WithDisableKeepAlives(), WithMaxIdleConns(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, ok := api.Client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T, want *http.Transport", api.Client.Transport)
+	}
+
+	if !tr.DisableKeepAlives {
+		t.Error("DisableKeepAlives = false, want true")
+	}
+
+	if tr.MaxIdleConns != 5 || tr.MaxIdleConnsPerHost != 5 {
+		t.Errorf("MaxIdleConns = %d/%d, want 5/5", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+
+	if http.DefaultTransport.(*http.Transport).DisableKeepAlives {
+		t.Error("http.DefaultTransport has been modified")
+	}
+}
+
+func TestTransportOptionsConflictWithHTTPClient(t *testing.T) {
+	_, err := New("app-id", "app-secret", WithHTTPClient(&http.Client{}), WithDisableKeepAlives())
+	if !errors.Is(err, ErrConflictingOptions) {
+		t.Errorf("New() error = %v, want ErrConflictingOptions", err)
+	}
+}
+
+func TestForceHTTP2ReusesConnection(t *testing.T) {
+	var conns int32
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			http.Error(w, "HTTP/2 required", http.StatusHTTPVersionNotSupported)
+			return
+		}
+
+		jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1}}`)(w, r)
+	}))
+	s.EnableHTTP2 = true
+	s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate())
+
+	api, err := New("app-id", "app-secret",
+		WithBaseURL(s.URL+"/api/"),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithForceHTTP2(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first request establishes the connection
+	if _, err := api.Info("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := api.Info("token"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&conns); got != 1 {
+		t.Errorf("connections = %d, want 1", got)
+	}
+}
+
+func TestForceHTTP2RejectsHTTP1(t *testing.T) {
+	s := httptest.NewUnstartedServer(jsonHandler(http.StatusOK, `{"success":true,"info":{"id":1}}`))
+	// the failing handshake is expected
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate())
+
+	api, err := New("app-id", "app-secret",
+		WithBaseURL(s.URL+"/api/"),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithForceHTTP2(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("token"); err == nil {
+		t.Error("Info() = nil error, want an error from a server without HTTP/2")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var host string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// proxied requests carry the absolute url of the target
+		host = r.URL.Host
+		jsonHandler(http.StatusOK, infoOK)(w, r)
+	}))
+	defer proxy.Close()
+
+	api, err := New("app-id", "app-secret", WithBaseURL("http://clef.invalid/api/"), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if host != "clef.invalid" {
+		t.Errorf("proxied host = %q, want clef.invalid", host)
+	}
+
+	if _, err := New("app-id", "app-secret", WithProxy("not a url")); err == nil {
+		t.Error("WithProxy with an invalid url = nil error")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	s := httptest.NewUnstartedServer(jsonHandler(http.StatusOK, infoOK))
+	// the failing handshake is expected
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	defer s.Close()
+
+	api, err := New("app-id", "app-secret", WithBaseURL(s.URL+"/api/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("token"); err == nil {
+		t.Error("Info() with an unknown certificate = nil error")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate())
+
+	api, err = New("app-id", "app-secret", WithBaseURL(s.URL+"/api/"), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Info("token"); err != nil {
+		t.Errorf("Info() with the pinned certificate = %v", err)
+	}
+
+	if _, err := New("app-id", "app-secret", WithHTTPClient(&http.Client{}), WithTLSConfig(&tls.Config{})); !errors.Is(err, ErrConflictingOptions) {
+		t.Errorf("WithTLSConfig and WithHTTPClient = %v, want ErrConflictingOptions", err)
+	}
+}
+
+// BenchmarkConnectionReuse reports the connections opened per request for
+// a pooled and a non pooled transport
+func BenchmarkConnectionReuse(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		opts []Option
+	}{
+		{"pooled", []Option{WithMaxIdleConns(10), WithIdleConnTimeout(time.Minute)}},
+		{"no keep-alives", []Option{WithDisableKeepAlives()}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var conns int32
+
+			s := httptest.NewUnstartedServer(jsonHandler(http.StatusOK, infoOK))
+			s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&conns, 1)
+				}
+			}
+			s.Start()
+			defer s.Close()
+
+			api, err := New("app-id", "app-secret", append([]Option{WithBaseURL(s.URL + "/api/")}, bm.opts...)...)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := api.Info("token"); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(atomic.LoadInt32(&conns))/float64(b.N), "conns/op")
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package clef
+
+import "net/http"
+
+// serverVersionHeader is the response header carrying the version of the
+// Clef API that served the request
+const serverVersionHeader = "X-Clef-API-Version"
+
+// LastServerVersion returns the API version reported by the most recent
+// response that carried one, or an empty string when none did. Operators
+// can use it to notice when the upstream API changes.
+func (api *API) LastServerVersion() string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	return api.serverVersion
+}
+
+func (api *API) updateServerVersion(h http.Header) {
+	v := h.Get(serverVersionHeader)
+	if v == "" {
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if api.serverVersion != "" && api.serverVersion != v {
+		api.log.Debugf("clef server version changed from=%q to=%q", api.serverVersion, v)
+	}
+
+	api.serverVersion = v
+}
@@ -0,0 +1,36 @@
+package clef
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLastServerVersion(t *testing.T) {
+	h, _ := sequence(
+		withHeader(serverVersionHeader, "2016-01-01", jsonHandler(http.StatusOK, infoOK)),
+		jsonHandler(http.StatusOK, infoOK),
+		withHeader(serverVersionHeader, "2016-06-01", jsonHandler(http.StatusOK, infoOK)),
+	)
+
+	logger := &recordingLogger{}
+	api := newTestAPI(t, h, WithLogger(logger))
+
+	if v := api.LastServerVersion(); v != "" {
+		t.Errorf("LastServerVersion() = %q before any request, want empty", v)
+	}
+
+	for _, want := range []string{"2016-01-01", "2016-01-01", "2016-06-01"} {
+		if _, err := api.Info("token"); err != nil {
+			t.Fatal(err)
+		}
+
+		if v := api.LastServerVersion(); v != want {
+			t.Errorf("LastServerVersion() = %q, want %q", v, want)
+		}
+	}
+
+	if !strings.Contains(strings.Join(logger.debug, "\n"), `clef server version changed from="2016-01-01" to="2016-06-01"`) {
+		t.Errorf("version change not logged: %q", logger.debug)
+	}
+}
@@ -0,0 +1,86 @@
+package clef
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LogoutWebhookHandler returns a handler for the Clef logout webhook. Clef
+// posts a logout_token when a user logs out from their phone; the handler
+// exchanges it for the Clef ID and calls onLogout, so the application can
+// end the sessions of that user.
+func (api *API) LogoutWebhookHandler(onLogout func(clefID ClefID)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lr, err := api.LogoutContext(r.Context(), r.PostForm.Get("logout_token"), WithLogoutSource(SourceWebhook))
+
+		var e *Error
+		if errors.Is(err, ErrEmptyToken) || (errors.As(err, &e) && e.StatusCode < 500) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+
+		onLogout(lr.ID)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// WebhookTolerance is the maximum age of a signed webhook request accepted
+// by VerifyWebhookSignature, limiting the window for replaying a captured
+// request
+const WebhookTolerance = 5 * time.Minute
+
+// SignRequest returns the timestamp (unix seconds) and the hex encoded
+// HMAC-SHA256 signature of body signed at t using secret. The timestamp is
+// part of the signed payload. It is the inverse of VerifyWebhookSignature
+// and mainly useful for testing.
+func SignRequest(secret string, t time.Time, body []byte) (timestamp, signature string) {
+	timestamp = strconv.FormatInt(t.Unix(), 10)
+	return timestamp, sign(secret, timestamp, body)
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature returns true if signature is the hex encoded
+// HMAC-SHA256 signature of timestamp and body using secret, proving the
+// request originated from a party knowing the secret, and the timestamp is
+// within WebhookTolerance of now, so captured requests can't be replayed
+// later.
+func VerifyWebhookSignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := now.Sub(time.Unix(ts, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return false
+	}
+
+	expected := sign(secret, timestamp, body)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
@@ -0,0 +1,86 @@
+package clef
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte("logout_token=abc")
+	timestamp, signature := SignRequest("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		want      bool
+	}{
+		{"valid", "secret", timestamp, signature, body, now, true},
+		{"within tolerance", "secret", timestamp, signature, body, now.Add(WebhookTolerance), true},
+		{"replayed", "secret", timestamp, signature, body, now.Add(WebhookTolerance + time.Second), false},
+		{"from the future", "secret", timestamp, signature, body, now.Add(-WebhookTolerance - time.Second), false},
+		{"other timestamp", "secret", "1700000001", signature, body, now, false},
+		{"invalid timestamp", "secret", "now", signature, body, now, false},
+		{"other secret", "other", timestamp, signature, body, now, false},
+		{"tampered body", "secret", timestamp, signature, []byte("logout_token=abd"), now, false},
+		{"empty signature", "secret", timestamp, "", body, now, false},
+	}
+
+	for _, tt := range tests {
+		if got := VerifyWebhookSignature(tt.secret, tt.timestamp, tt.signature, tt.body, tt.now); got != tt.want {
+			t.Errorf("%s: VerifyWebhookSignature() = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogoutWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		upstream http.HandlerFunc
+		status   int
+		clefID   ClefID
+	}{
+		{"success", "POST", "logout_token=lt", jsonHandler(http.StatusOK, `{"success":true,"clef_id":42}`), http.StatusOK, 42},
+		{"wrong method", "GET", "", nil, http.StatusMethodNotAllowed, 0},
+		{"missing token", "POST", "", nil, http.StatusBadRequest, 0},
+		{"rejected token", "POST", "logout_token=lt", jsonHandler(http.StatusForbidden, `{"error":"Invalid token.","message":"Invalid token."}`), http.StatusBadRequest, 0},
+		{"upstream error", "POST", "logout_token=lt", jsonHandler(http.StatusInternalServerError, `{"error":"Internal error"}`), http.StatusBadGateway, 0},
+	}
+
+	for _, tt := range tests {
+		upstream := tt.upstream
+		if upstream == nil {
+			upstream = func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("%s: unexpected request to the Clef API", tt.name)
+			}
+		}
+
+		var got ClefID
+		handler := newTestAPI(t, upstream).LogoutWebhookHandler(func(clefID ClefID) {
+			got = clefID
+		})
+
+		r := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+
+		if got != tt.clefID {
+			t.Errorf("%s: onLogout called with %d, want %d", tt.name, got, tt.clefID)
+		}
+	}
+}